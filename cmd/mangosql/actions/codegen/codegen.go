@@ -36,22 +36,34 @@ func Action(ctx *cli.Context) error {
 
 	name := ctx.Args().Get(0)
 	return generate(generateOptions{
-		Src:     name,
-		Output:  ctx.String("output"),
-		Inline:  ctx.Bool("inline"),
-		Package: ctx.String("package"),
-		Driver:  driver,
-		Logger:  logger,
+		Src:          name,
+		Output:       ctx.String("output"),
+		Inline:       ctx.Bool("inline"),
+		Package:      ctx.String("package"),
+		Driver:       driver,
+		Logger:       logger,
+		Fake:         ctx.Bool("fake"),
+		Factories:    ctx.Bool("factories"),
+		Notify:       ctx.Bool("notify"),
+		Queries:      ctx.String("queries"),
+		GroupQueries: ctx.Bool("group-queries"),
+		Lenient:      ctx.Bool("lenient"),
 	})
 }
 
 type generateOptions struct {
-	Src     string
-	Output  string
-	Inline  bool
-	Package string
-	Driver  string
-	Logger  string
+	Src          string
+	Output       string
+	Inline       bool
+	Package      string
+	Driver       string
+	Logger       string
+	Fake         bool
+	Factories    bool
+	Notify       bool
+	Queries      string
+	GroupQueries bool
+	Lenient      bool
 }
 
 func generate(opts generateOptions) error {
@@ -68,18 +80,41 @@ func generate(opts generateOptions) error {
 		return err
 	}
 
-	// find and parse queries
-	if queries, err := input.ParseInputQueries(opts.Src); err == nil {
-		err = internal.ParseQueries(schema, queries)
-		if err != nil {
-			return err
+	// find and parse queries, in the dialect of the driver
+	schema.Driver = opts.Driver
+	files, err := input.ParseInputQueries(opts.Src, opts.Queries)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err = internal.ParseQueries(schema, file.SQL); err != nil {
+			var queryErr *internal.QueryError
+			if errors.As(err, &queryErr) {
+				queryErr.File = file.Path
+				return queryErr
+			}
+			return fmt.Errorf("%s: %w", file.Path, err)
+		}
+	}
+
+	// validate queries (errors, or warnings in lenient mode)
+	if errs := internal.ValidateQueries(schema, files); len(errs) > 0 {
+		level := "\033[31mError\033[0m"
+		if opts.Lenient {
+			level = "\033[33mWarning\033[0m"
+		}
+		for _, err := range errs {
+			fmt.Printf("%s: %s\n", level, err)
+		}
+		if !opts.Lenient {
+			return fmt.Errorf("%d invalid custom queries", len(errs))
 		}
 	}
 
 	var b bytes.Buffer
 	contents := bufio.NewWriter(&b)
 
-	if err = generator.Generate(schema, contents, opts.Package, opts.Driver, opts.Logger); err != nil {
+	if err = generator.Generate(schema, contents, opts.Package, opts.Driver, opts.Logger, opts.Fake, opts.Factories, opts.Notify, opts.GroupQueries); err != nil {
 		return err
 	}
 
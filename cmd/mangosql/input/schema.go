@@ -1,10 +1,15 @@
 package input
 
 import (
+	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+
+	"github.com/kefniark/mango-sql/internal/core"
 )
 
 func ParseInputSchema(src string) (string, error) {
@@ -25,10 +30,41 @@ func ParseInputSchema(src string) (string, error) {
 	return string(data), nil
 }
 
-func ParseInputQueries(src string) (string, error) {
+// Find the custom queries: the files matched by pattern (a folder of .sql files or a glob like `db/queries/*.sql`),
+// or by default the queries.sql next to the schema
+func ParseInputQueries(src string, pattern string) ([]core.QueryFile, error) {
+	if pattern == "" {
+		return parseDefaultQueries(src)
+	}
+
+	if stat, err := os.Stat(pattern); err == nil && stat.IsDir() {
+		pattern = path.Join(pattern, "*.sql")
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no query file matches %s", pattern)
+	}
+	slices.Sort(files)
+
+	queries := []core.QueryFile{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, core.QueryFile{Path: file, SQL: string(data)})
+	}
+	return queries, nil
+}
+
+func parseDefaultQueries(src string) ([]core.QueryFile, error) {
 	stat, err := os.Stat(src)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var queriesFilePath string
@@ -41,12 +77,12 @@ func ParseInputQueries(src string) (string, error) {
 	if _, err = os.Stat(queriesFilePath); err == nil {
 		data, err := os.ReadFile(queriesFilePath)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		return string(data), nil
+		return []core.QueryFile{{Path: queriesFilePath, SQL: string(data)}}, nil
 	}
 
-	return "", nil
+	return nil, nil
 }
 
 func parseMigrationFolder(folderName string) string {
@@ -55,6 +55,31 @@ Example: mangosql --output db/file.go db/schema.sql`,
 				Value:   "none",
 				Usage:   "Logging library",
 			},
+			&cli.BoolFlag{
+				Name:  "fake",
+				Usage: "Also generate an in-memory fake of the client (for unit tests)",
+			},
+			&cli.BoolFlag{
+				Name:  "factories",
+				Usage: "Also generate test data factories, inserting rows with random values (for tests)",
+			},
+			&cli.BoolFlag{
+				Name:  "notify",
+				Usage: "Also generate the LISTEN / NOTIFY helpers and the change notifications of the tables (pgx only)",
+			},
+			&cli.StringFlag{
+				Name:    "queries",
+				Aliases: []string{"q"},
+				Usage:   "Custom queries, a folder of .sql files or a glob (default: queries.sql next to the schema)",
+			},
+			&cli.BoolFlag{
+				Name:  "group-queries",
+				Usage: "Attach the custom queries to the client of their main table (db.User.NotDeleted() instead of db.Queries.UserNotDeleted())",
+			},
+			&cli.BoolFlag{
+				Name:  "lenient",
+				Usage: "Only warn about the invalid custom queries (unknown table or column, ambiguous column, duplicate name, ...) instead of failing the generation",
+			},
 		},
 		Action: codegen.Action,
 		Commands: []*cli.Command{
@@ -37,6 +37,12 @@ export default defineConfig({
           { text: 'ERD Diagram', link: '/features/diagram' },
           { text: 'Logging', link: '/features/logging' },
           { text: 'Soft Delete', link: '/features/soft-delete' },
+          { text: 'JSON Columns', link: '/features/json' },
+          { text: 'Batch Queries', link: '/features/batch' },
+          { text: 'Listen / Notify', link: '/features/notify' },
+          { text: 'Interfaces & Mocking', link: '/features/mocking' },
+          { text: 'In-Memory Fake', link: '/features/fake' },
+          { text: 'Test Data Factories', link: '/features/factories' },
           // { text: 'Migrations', link: '/api/mutations' },
           { text: 'Benchmark', link: '/bench/bench' },
         ]
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+var (
+	regEnumType   = regexp.MustCompile(`(?i)CREATE\s+TYPE\s+(\S+)\s+AS\s+ENUM\s*\(([^)]*)\)`)
+	regInlineEnum = regexp.MustCompile(`(?i)^\s*enum\s*\(([^)]*)\)`)
+	regTypeLength = regexp.MustCompile(`(?i)^\s*(character varying|varchar|nvarchar|character|char|bpchar)\s*\(\s*(\d+)\s*\)`)
+)
+
+type columnDetails struct {
+	Length int
+	Enum   []string
+}
+
+// Enum values (postgres `CREATE TYPE ... AS ENUM`, mysql `ENUM(...)`) and text lengths are lost during normalization,
+// they are extracted from the raw sql.
+func findColumnDetails(sql string) map[string]map[string]columnDetails {
+	enums := map[string][]string{}
+	for _, match := range regEnumType.FindAllStringSubmatch(sql, -1) {
+		enums[normalizeName(match[1])] = splitEnumValues(match[2])
+	}
+
+	details := map[string]map[string]columnDetails{}
+	for _, table := range findTableContents(sql) {
+		for _, field := range table.Fields {
+			detail := columnDetails{}
+			if match := regInlineEnum.FindStringSubmatch(field.Type); match != nil {
+				detail.Enum = splitEnumValues(match[1])
+			} else if values, ok := enums[normalizeName(firstWord(field.Type))]; ok {
+				detail.Enum = values
+			}
+			if match := regTypeLength.FindStringSubmatch(field.Type); match != nil {
+				detail.Length, _ = strconv.Atoi(match[2])
+			}
+			if detail.Length == 0 && detail.Enum == nil {
+				continue
+			}
+
+			name := normalizeName(table.Name)
+			if _, ok := details[name]; !ok {
+				details[name] = map[string]columnDetails{}
+			}
+			details[name][normalizeName(field.Name)] = detail
+		}
+	}
+
+	return details
+}
+
+func applyColumnDetails(schema *core.SQLSchema, details map[string]map[string]columnDetails) {
+	for name, columns := range details {
+		table, ok := schema.Tables[name]
+		if !ok {
+			continue
+		}
+
+		for columnName, detail := range columns {
+			column, ok := table.Columns[columnName]
+			if !ok {
+				continue
+			}
+			column.Length = detail.Length
+			column.Enum = detail.Enum
+		}
+	}
+}
+
+func splitEnumValues(list string) []string {
+	values := []string{}
+	for _, value := range strings.Split(list, ",") {
+		value = strings.Trim(strings.TrimSpace(value), "'\"")
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+var (
+	regCommentOnTable  = regexp.MustCompile(`(?i)COMMENT\s+ON\s+TABLE\s+([\w."\x60]+)\s+IS\s+'((?:[^']|'')*)'`)
+	regCommentOnColumn = regexp.MustCompile(`(?i)COMMENT\s+ON\s+COLUMN\s+([\w."\x60]+)\.([\w"\x60]+)\s+IS\s+'((?:[^']|'')*)'`)
+	regInlineComment   = regexp.MustCompile(`(?i)\bCOMMENT\s*=?\s*'((?:[^']|'')*)'`)
+)
+
+// Comments of the tables and columns, from `COMMENT ON TABLE/COLUMN ... IS '...'` (postgres)
+// or inline `COMMENT '...'` (mysql). They are removed during normalization, so they are extracted from the raw sql.
+// The comment of the table itself is stored as the column ""
+func findComments(sql string) map[string]map[string]string {
+	comments := map[string]map[string]string{}
+	add := func(table string, column string, comment string) {
+		name := normalizeName(table)
+		if _, ok := comments[name]; !ok {
+			comments[name] = map[string]string{}
+		}
+		comments[name][column] = strings.ReplaceAll(comment, "''", "'")
+	}
+
+	for _, table := range findTableContents(sql) {
+		for _, field := range table.Fields {
+			if match := regInlineComment.FindStringSubmatch(field.Type); match != nil {
+				add(table.Name, normalizeName(field.Name), match[1])
+			}
+		}
+		if table.MetaStart >= 0 {
+			if match := regInlineComment.FindStringSubmatch(sql[table.MetaStart:table.MetaEnd]); match != nil {
+				add(table.Name, "", match[1])
+			}
+		}
+	}
+
+	for _, match := range regCommentOnTable.FindAllStringSubmatch(sql, -1) {
+		add(match[1], "", match[2])
+	}
+	for _, match := range regCommentOnColumn.FindAllStringSubmatch(sql, -1) {
+		add(match[1], normalizeName(match[2]), match[3])
+	}
+
+	return comments
+}
+
+func applyComments(schema *core.SQLSchema, comments map[string]map[string]string) {
+	for name, columns := range comments {
+		table, ok := schema.Tables[name]
+		if !ok {
+			continue
+		}
+
+		for columnName, comment := range columns {
+			if columnName == "" {
+				table.Comment = comment
+				continue
+			}
+			if column, ok := table.Columns[columnName]; ok {
+				column.Comment = comment
+			}
+		}
+	}
+}
@@ -5,33 +5,79 @@ import "fmt"
 type SQLSchema struct {
 	Tables  map[string]*SQLTable
 	Queries []SQLQuery
+
+	// Named conditions on a table (`-- filter: Active`), their condition is the Where of the query
+	Filters []SQLQuery
+
+	// Dialect of the custom queries (mysql, mariadb or sqlite), postgres when empty
+	Driver string
 }
 
 type SQLTable struct {
 	Name    string
+	Comment string
 	Columns map[string]*SQLColumn
 
 	Constraints []*SQLTableConstraint
 	Indexes     []*SQLTableIndex
 	References  []*SQLTableReference
 	Referenced  []*SQLTableReference
+	Searches    []*SQLTableSearch
+
+	// Read-only table (CREATE VIEW), a materialized view can be refreshed
+	View         bool
+	Materialized bool
 
 	Order int
 }
 
 type SQLQuery struct {
-	Query  string
-	Method string
-	Name   string
+	Query   string
+	Method  string
+	Name    string
+	Comment string
 
 	Select         string
 	SelectOriginal string
 	SelectFields   []*SQLColumn
 
+	// Main table of the query (the first table of the FROM, or the written table)
+	Table string
+
 	From    string
 	Where   string
 	GroupBy []string
 	Having  string
+
+	// Kept as written in the builder (DISTINCT ON, ORDER BY items), the limit and offset are
+	// a number or the name of a parameter
+	Distinct   bool
+	DistinctOn string
+	OrderBy    []string
+	Limit      string
+	Offset     string
+
+	Params       []*SQLColumn
+	WhereParams  []string
+	HavingParams []string
+
+	// Write queries (INSERT, UPDATE, DELETE) are executed as written, with `?` placeholders
+	Statement       string
+	StatementParams []string
+
+	// Selects executed as written (CTEs, derived tables, set operations), filtered through a subquery
+	Derived bool
+
+	// Rows of joined tables grouped under their parent row (`-- nest: posts`),
+	// the parent rows are identified by the primary keys of the other tables
+	Nest     []*SQLQueryNest
+	NestKeys []string
+}
+
+type SQLQueryNest struct {
+	Name   string
+	Fields []*SQLColumn
+	Keys   []string
 }
 
 type SQLColumn struct {
@@ -40,10 +86,14 @@ type SQLColumn struct {
 	Ref        string
 	Type       string
 	TypeSQL    string
+	TypeGo     string
 	Nullable   bool
 	Table      string
 	TableAs    string
 	HasDefault bool
+	Length     int
+	Enum       []string
+	Comment    string
 
 	Order int
 }
@@ -59,6 +109,23 @@ type SQLTableIndex struct {
 	Columns []string
 }
 
+const (
+	SearchTsvector = "TSVECTOR"
+	SearchFulltext = "FULLTEXT"
+	SearchFTS5     = "FTS5"
+)
+
+// Full-text search source of a table
+// (postgres tsvector column or to_tsvector index, mysql FULLTEXT index, sqlite FTS5 virtual table).
+type SQLTableSearch struct {
+	Name    string
+	Columns []string
+	Type    string
+	Expr    string
+	Config  string
+	RowID   string
+}
+
 type SQLTableReference struct {
 	Name         string
 	Columns      []string
@@ -84,16 +151,50 @@ func (s *SQLSchema) Debug() {
 		for _, ref := range table.References {
 			fmt.Printf("  ref: %s %s => %s(%s)\n", ref.Name, ref.Columns, ref.Table, ref.TableColumns)
 		}
+
+		for _, search := range table.Searches {
+			fmt.Printf("  search: %s %s %s\n", search.Name, search.Columns, search.Type)
+		}
 	}
 }
 
 type TableDeps struct {
 	Names []string
 	As    string
+
+	// Outer side of a join (LEFT, RIGHT, FULL), its columns can be null
+	Nullable bool
+}
+
+// Methods of the custom queries (`-- queryOne: Name`), which define the shape of the generated method
+const (
+	QueryOne  = "queryOne"
+	QueryMany = "queryMany"
+	Exec      = "exec"
+	ExecRows  = "execRows"
+
+	// Named condition on a table (`-- filter: Active`), added to its filters instead of a query method
+	Filter = "filter"
+)
+
+// File of custom queries (e.g. queries.sql)
+type QueryFile struct {
+	Path string
+	SQL  string
 }
 
 type QueryMacro struct {
-	Method string
-	Name   string
-	Query  string
+	Method  string
+	Name    string
+	Comment string
+	Query   string
+	Params  []string
+	Nest    []string
+
+	// Position of the annotation in the queries
+	Line   int
+	Column int
+
+	// Already matched with a parsed statement
+	Used bool
 }
@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+// The custom queries are parsed with the postgres (cockroachDB) syntax, the mysql and sqlite specific syntax
+// is rewritten before parsing (cf normalizeQueries), and restored in the generated queries (cf dialectQuery).
+// The rewrites never add or remove lines or `;`, to keep the annotations and the error locations in place
+
+type sqlSegmentKind int
+
+const (
+	segmentCode sqlSegmentKind = iota
+	segmentString
+	segmentIdentifier
+	segmentBacktick
+	segmentComment
+)
+
+type sqlSegment struct {
+	kind sqlSegmentKind
+	text string
+}
+
+// Split a query in code, quoted strings, quoted identifiers and line comments
+func splitSegments(sql string) []sqlSegment {
+	segments := []sqlSegment{}
+	start := 0
+	push := func(kind sqlSegmentKind, end int) {
+		if end > start {
+			segments = append(segments, sqlSegment{kind: kind, text: sql[start:end]})
+		}
+		start = end
+	}
+
+	for i := 0; i < len(sql); i++ {
+		kind := segmentCode
+		switch {
+		case sql[i] == '\'':
+			kind = segmentString
+		case sql[i] == '"':
+			kind = segmentIdentifier
+		case sql[i] == '`':
+			kind = segmentBacktick
+		case strings.HasPrefix(sql[i:], "--"):
+			kind = segmentComment
+		default:
+			continue
+		}
+
+		push(segmentCode, i)
+		end := len(sql)
+		if kind == segmentComment {
+			if idx := strings.IndexByte(sql[i:], '\n'); idx >= 0 {
+				end = i + idx
+			}
+		} else {
+			// quotes are escaped by doubling them
+			for j := i + 1; j < len(sql); j++ {
+				if sql[j] != sql[i] {
+					continue
+				}
+				if j+1 < len(sql) && sql[j+1] == sql[i] {
+					j++
+					continue
+				}
+				end = j + 1
+				break
+			}
+		}
+		push(kind, end)
+		i = end - 1
+	}
+	push(segmentCode, len(sql))
+
+	return segments
+}
+
+// Split queries on the `;` which are not quoted or commented (same pieces as strings.Split otherwise)
+func splitStatements(sql string) []string {
+	statements := []string{}
+	start, offset := 0, 0
+	for _, segment := range splitSegments(sql) {
+		if segment.kind == segmentCode {
+			for i, char := range segment.text {
+				if char == ';' {
+					statements = append(statements, sql[start:offset+i])
+					start = offset + i + 1
+				}
+			}
+		}
+		offset += len(segment.text)
+	}
+	return append(statements, sql[start:])
+}
+
+func joinSegments(segments []sqlSegment) string {
+	var sb strings.Builder
+	for _, segment := range segments {
+		sb.WriteString(segment.text)
+	}
+	return sb.String()
+}
+
+// Change the quotes of a quoted string or identifier
+func requote(text string, quote string) string {
+	if len(text) < 2 {
+		return text
+	}
+	content := strings.ReplaceAll(text[1:len(text)-1], text[:1]+text[:1], text[:1])
+	return quote + strings.ReplaceAll(content, quote, quote+quote) + quote
+}
+
+var (
+	regDialectLimit     = regexp.MustCompile(`(?i)\bLIMIT([ \t]+)([\w$@]+)[ \t]*,[ \t]*([\w$@]+)`)
+	regDialectSeparator = regexp.MustCompile(`(?is)^(.*?)(\s+ORDER\s+BY\s+.*?)?\s+SEPARATOR\s+('(?:[^']|'')*')\s*$`)
+	regDialectNotRegexp = regexp.MustCompile(`(?i)\bNOT\s+(REGEXP|RLIKE)\b`)
+	regDialectRegexp    = regexp.MustCompile(`(?i)\b(REGEXP|RLIKE)\b`)
+	regDialectGlob      = regexp.MustCompile(`(?i)\bGLOB\b`)
+	regDialectCast      = regexp.MustCompile(`(?i)\bAS\s+(UNSIGNED|SIGNED)(\s+INTEGER)?\s*\)`)
+)
+
+// Rewrite the mysql and sqlite syntax of the custom queries which is not supported by the parser
+func normalizeQueries(sql string, driver string) string {
+	mysql := driver == core.DriverMysql || driver == core.DriverMariaDB
+	if !mysql && driver != core.DriverSqlite {
+		return sql
+	}
+
+	segments := splitSegments(sql)
+	for i, segment := range segments {
+		switch segment.kind {
+		case segmentIdentifier:
+			// mysql strings can be double quoted
+			if mysql {
+				segments[i] = sqlSegment{kind: segmentString, text: requote(segment.text, "'")}
+			}
+		case segmentBacktick:
+			segments[i] = sqlSegment{kind: segmentIdentifier, text: requote(segment.text, `"`)}
+		}
+	}
+
+	for i, segment := range segments {
+		switch segment.kind {
+		case segmentCode:
+			text := segment.text
+			// LIMIT offset, count
+			text = regDialectLimit.ReplaceAllString(text, "LIMIT${1}${3} OFFSET $2")
+			text = regDialectNotRegexp.ReplaceAllString(text, "!~")
+			text = regDialectRegexp.ReplaceAllString(text, "~")
+			if mysql {
+				text = regDialectCast.ReplaceAllString(text, "AS INT8)")
+			} else {
+				text = regDialectGlob.ReplaceAllString(text, "SIMILAR TO")
+			}
+			segments[i].text = text
+		}
+	}
+
+	sql = joinSegments(segments)
+	if mysql {
+		sql = replaceSeparator(sql)
+	}
+	return sql
+}
+
+// The separator of GROUP_CONCAT is a parameter for the parser: GROUP_CONCAT(x ORDER BY y SEPARATOR ', ') -> GROUP_CONCAT(x, ', ' ORDER BY y)
+func replaceSeparator(sql string) string {
+	matches := regDialectConcat.FindAllStringIndex(sql, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		start := matches[i][1]
+		end, _ := closingParenthesis(sql, start)
+		if end < 0 {
+			continue
+		}
+		args := sql[start:end]
+		replaced := regDialectSeparator.ReplaceAllString(args, "$1, $3$2")
+		replaced += strings.Repeat("\n", strings.Count(args, "\n")-strings.Count(replaced, "\n"))
+		sql = sql[:start] + replaced + sql[end:]
+	}
+	return sql
+}
+
+var (
+	regDialectNotMatch   = regexp.MustCompile(`\s!~\s`)
+	regDialectMatch      = regexp.MustCompile(`\s~\s`)
+	regDialectSimilar    = regexp.MustCompile(`\bSIMILAR TO\b`)
+	regDialectConcat     = regexp.MustCompile(`(?i)\bgroup_concat\(`)
+	regDialectConcatSep  = regexp.MustCompile(`(?is)^('(?:[^']|'')*')(\s+ORDER BY\s+.*)?$`)
+	regDialectCastString = regexp.MustCompile(`\bAS STRING\)`)
+	regDialectCastInt    = regexp.MustCompile(`\bAS INT8\)`)
+	regDialectCastFloat  = regexp.MustCompile(`\bAS FLOAT8\)`)
+)
+
+// Restore the mysql and sqlite syntax in a query formatted by the parser
+func dialectSQL(sql string, driver string) string {
+	mysql := driver == core.DriverMysql || driver == core.DriverMariaDB
+	if !mysql && driver != core.DriverSqlite {
+		return sql
+	}
+
+	if mysql {
+		sql = restoreSeparator(sql)
+	}
+
+	types := map[*regexp.Regexp]string{regDialectCastString: "AS TEXT)", regDialectCastInt: "AS INTEGER)", regDialectCastFloat: "AS REAL)"}
+	if mysql {
+		types = map[*regexp.Regexp]string{regDialectCastString: "AS CHAR)", regDialectCastInt: "AS SIGNED)", regDialectCastFloat: "AS DOUBLE)"}
+	}
+
+	segments := splitSegments(sql)
+	for i, segment := range segments {
+		switch segment.kind {
+		case segmentIdentifier:
+			if mysql {
+				segments[i].text = requote(segment.text, "`")
+			}
+		case segmentCode:
+			text := segment.text
+			text = regDialectNotMatch.ReplaceAllString(text, " NOT REGEXP ")
+			text = regDialectMatch.ReplaceAllString(text, " REGEXP ")
+			if !mysql {
+				text = regDialectSimilar.ReplaceAllString(text, "GLOB")
+			}
+			for reg, value := range types {
+				text = reg.ReplaceAllString(text, value)
+			}
+			segments[i].text = text
+		}
+	}
+
+	return joinSegments(segments)
+}
+
+// The mysql separator of GROUP_CONCAT is not a parameter: GROUP_CONCAT(x, ', ' ORDER BY y) -> GROUP_CONCAT(x ORDER BY y SEPARATOR ', ')
+func restoreSeparator(sql string) string {
+	matches := regDialectConcat.FindAllStringIndex(sql, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		start := matches[i][1]
+		end, comma := closingParenthesis(sql, start)
+		if end < 0 || comma < 0 {
+			continue
+		}
+		if match := regDialectConcatSep.FindStringSubmatch(strings.TrimSpace(sql[comma+1 : end])); match != nil {
+			sql = sql[:comma] + match[2] + " SEPARATOR " + match[1] + sql[end:]
+		}
+	}
+	return sql
+}
+
+// Position of the parenthesis closing a function call, and of its last argument separator
+func closingParenthesis(sql string, start int) (int, int) {
+	depth := 0
+	comma := -1
+	offset := start
+	for _, segment := range splitSegments(sql[start:]) {
+		if segment.kind != segmentCode {
+			offset += len(segment.text)
+			continue
+		}
+		for i, char := range segment.text {
+			switch char {
+			case '(':
+				depth++
+			case ')':
+				if depth == 0 {
+					return offset + i, comma
+				}
+				depth--
+			case ',':
+				if depth == 0 {
+					comma = offset + i
+				}
+			}
+		}
+		offset += len(segment.text)
+	}
+	return -1, -1
+}
+
+// Restore the dialect of the parsed queries
+func dialectQuery(query *core.SQLQuery, driver string) {
+	convert := func(values []string) {
+		for i, value := range values {
+			values[i] = dialectSQL(value, driver)
+		}
+	}
+
+	for _, value := range []*string{&query.Query, &query.Select, &query.SelectOriginal, &query.From, &query.Where, &query.Having, &query.DistinctOn, &query.Statement} {
+		*value = dialectSQL(*value, driver)
+	}
+	convert(query.GroupBy)
+	convert(query.OrderBy)
+}
@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/auxten/postgresql-parser/pkg/sql/parser"
+	"github.com/auxten/postgresql-parser/pkg/sql/sem/tree"
+	"github.com/auxten/postgresql-parser/pkg/sql/types"
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+var (
+	stringFunctions = []string{
+		"lower", "upper", "trim", "ltrim", "rtrim", "btrim", "concat", "concat_ws", "substring", "substr", "replace",
+		"left", "right", "lpad", "rpad", "initcap", "reverse", "repeat", "md5", "to_char", "format", "string_agg", "group_concat",
+	}
+	intFunctions       = []string{"count", "length", "char_length", "character_length", "octet_length", "strpos", "position", "row_number", "rank", "dense_rank"}
+	floatFunctions     = []string{"avg", "random", "extract", "date_part", "stddev", "variance"}
+	timestampFunctions = []string{"now", "current_timestamp", "localtimestamp", "statement_timestamp", "clock_timestamp", "transaction_timestamp"}
+	sameTypeFunctions  = []string{"max", "min", "abs", "round", "ceil", "ceiling", "floor", "greatest", "least", "any_value", "first_value", "last_value"}
+	boolFunctions      = []string{"bool_and", "bool_or", "every"}
+	jsonFunctions      = []string{"json_agg", "jsonb_agg", "json_build_object", "jsonb_build_object", "json_build_array", "jsonb_build_array", "to_json", "to_jsonb", "json_object_agg", "jsonb_object_agg"}
+	uuidFunctions      = []string{"gen_random_uuid", "uuid_generate_v4", "uuid"}
+)
+
+// Type of a select expression which is not a column (e.g. `count(*)`, `CAST(x AS INT)`, `lower(users.name)`),
+// inferred from the parsed expression (nil when the type cannot be inferred), and if it can be null
+func inferExprType(field string, tables []core.TableDeps, schema *core.SQLSchema) (*core.SQLColumn, bool) {
+	expr, err := parser.ParseExpr(field)
+	if err != nil {
+		return nil, true
+	}
+	// an unknown column, the column lookup already failed
+	if _, ok := expr.(*tree.UnresolvedName); ok {
+		return nil, true
+	}
+	return exprType(expr, tables, schema), exprNullable(expr, tables, schema)
+}
+
+//nolint:gocognit,gocyclo // One case per kind of expression
+func exprType(expr tree.Expr, tables []core.TableDeps, schema *core.SQLSchema) *core.SQLColumn {
+	switch e := expr.(type) {
+	case *tree.ParenExpr:
+		return exprType(e.Expr, tables, schema)
+	case *tree.UnresolvedName:
+		columns := resolveTableColumns(e.String(), "", tables, schema, 0)
+		if len(columns) != 1 || columns[0].TypeSQL == "UNKNOWN" {
+			return nil
+		}
+		return &core.SQLColumn{Type: columns[0].Type, TypeSQL: columns[0].TypeSQL, TypeGo: columns[0].TypeGo}
+	case *tree.CastExpr:
+		return typeColumn(e.Type)
+	case *tree.AnnotateTypeExpr:
+		return typeColumn(e.Type)
+	case *tree.NumVal:
+		if strings.ContainsAny(e.String(), ".eE") {
+			return typeColumn(types.Float)
+		}
+		return typeColumn(types.Int)
+	case *tree.StrVal:
+		return typeColumn(types.String)
+	case *tree.DBool:
+		return typeColumn(types.Bool)
+	case *tree.UnaryExpr:
+		return exprType(e.Expr, tables, schema)
+	case *tree.ComparisonExpr, *tree.AndExpr, *tree.OrExpr, *tree.NotExpr, *tree.IsOfTypeExpr, *tree.RangeCond:
+		return typeColumn(types.Bool)
+	case *tree.CoalesceExpr:
+		return firstExprType(tables, schema, e.Exprs...)
+	case *tree.NullIfExpr:
+		return exprType(e.Expr1, tables, schema)
+	case *tree.IfExpr:
+		return firstExprType(tables, schema, e.True, e.Else)
+	case *tree.CaseExpr:
+		values := []tree.Expr{}
+		for _, when := range e.Whens {
+			values = append(values, when.Val)
+		}
+		return firstExprType(tables, schema, append(values, e.Else)...)
+	case *tree.BinaryExpr:
+		return binaryExprType(e, tables, schema)
+	case *tree.FuncExpr:
+		return funcExprType(e, tables, schema)
+	}
+	return nil
+}
+
+// Type of the first expression with a known type (COALESCE, CASE)
+func firstExprType(tables []core.TableDeps, schema *core.SQLSchema, exprs ...tree.Expr) *core.SQLColumn {
+	for _, expr := range exprs {
+		if expr == nil || expr == tree.DNull {
+			continue
+		}
+		if col := exprType(expr, tables, schema); col != nil {
+			return col
+		}
+	}
+	return nil
+}
+
+// Arithmetic keeps the type of its operands (int + float is a float), and a date moved by an interval stays a date
+func binaryExprType(expr *tree.BinaryExpr, tables []core.TableDeps, schema *core.SQLSchema) *core.SQLColumn {
+	if expr.Operator == tree.Concat {
+		return typeColumn(types.String)
+	}
+
+	left := exprType(expr.Left, tables, schema)
+	right := exprType(expr.Right, tables, schema)
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case isTimeType(left.Type) && isTimeType(right.Type) && expr.Operator == tree.Minus:
+		return nil
+	case isTimeType(right.Type):
+		return right
+	case isNumericType(left.Type) && isNumericType(right.Type) && !strings.HasPrefix(left.Type, "int"):
+		return left
+	case isNumericType(left.Type) && isNumericType(right.Type):
+		return right
+	}
+	return left
+}
+
+func funcExprType(expr *tree.FuncExpr, tables []core.TableDeps, schema *core.SQLSchema) *core.SQLColumn {
+	name := strings.ToLower(expr.Func.String())
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	switch {
+	case slices.Contains(intFunctions, name):
+		return typeColumn(types.Int)
+	case slices.Contains(floatFunctions, name):
+		return typeColumn(types.Float)
+	case slices.Contains(stringFunctions, name):
+		return typeColumn(types.String)
+	case slices.Contains(timestampFunctions, name):
+		return typeColumn(types.TimestampTZ)
+	case name == "current_date":
+		return typeColumn(types.Date)
+	case name == "date_trunc" && len(expr.Exprs) == 2:
+		return exprType(expr.Exprs[1], tables, schema)
+	case slices.Contains(boolFunctions, name):
+		return typeColumn(types.Bool)
+	case slices.Contains(jsonFunctions, name):
+		return typeColumn(types.Jsonb)
+	case slices.Contains(uuidFunctions, name):
+		return typeColumn(types.Uuid)
+	case slices.Contains(sameTypeFunctions, name):
+		return firstExprType(tables, schema, expr.Exprs...)
+	case name == "sum":
+		return sumExprType(firstExprType(tables, schema, expr.Exprs...), schema)
+	case name == "array_agg" && len(expr.Exprs) == 1:
+		if col := exprType(expr.Exprs[0], tables, schema); col != nil && col.TypeGo == "" {
+			return typeColumn(types.MakeArray(typeOf(col)))
+		}
+	}
+	return nil
+}
+
+// The sum of integers is an integer in sqlite, but a numeric in postgres (bigint overflows) and a DECIMAL in mysql
+func sumExprType(col *core.SQLColumn, schema *core.SQLSchema) *core.SQLColumn {
+	if col == nil || !strings.HasPrefix(col.Type, "int") || schema != nil && schema.Driver == core.DriverSqlite {
+		return col
+	}
+	return typeColumn(types.Decimal)
+}
+
+func typeColumn(t *types.T) *core.SQLColumn {
+	col := &core.SQLColumn{Type: t.String(), TypeSQL: t.SQLString()}
+	if col.TypeSQL == "STRING" {
+		col.TypeSQL = "TEXT"
+	}
+	return col
+}
+
+// Type of a column with a known type (cf typeColumn), used to build arrays
+func typeOf(col *core.SQLColumn) *types.T {
+	for _, t := range []*types.T{types.Int, types.Float, types.Decimal, types.Bool, types.Date, types.Timestamp, types.TimestampTZ, types.Uuid, types.Jsonb, types.Bytes} {
+		if t.String() == col.Type {
+			return t
+		}
+	}
+	if isNumericType(col.Type) && strings.HasPrefix(col.Type, "int") {
+		return types.Int
+	}
+	return types.String
+}
+
+func isNumericType(name string) bool {
+	return strings.HasPrefix(name, "int") || strings.HasPrefix(name, "float") || strings.HasPrefix(name, "decimal")
+}
+
+func isTimeType(name string) bool {
+	return strings.HasPrefix(name, "timestamp") || name == "date"
+}
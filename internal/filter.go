@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/auxten/postgresql-parser/pkg/sql/parser"
+	"github.com/auxten/postgresql-parser/pkg/sql/sem/tree"
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+// A named filter (`-- filter: Active`) is a boolean expression on a single table, parsed as
+// `SELECT * FROM <table> WHERE <expression>` to type its parameters like the custom queries
+func wrapFilters(sql string) (string, error) {
+	statements := splitStatements(sql)
+	for i, statement := range statements {
+		wrapped, err := wrapFilter(statement)
+		if err != nil {
+			return "", err
+		}
+		statements[i] = wrapped
+	}
+	return strings.Join(statements, ";"), nil
+}
+
+func wrapFilter(statement string) (string, error) {
+	lines := strings.Split(statement, "\n")
+	idx := findMacroLine(lines)
+	if idx < 0 {
+		return statement, nil
+	}
+	match := parseMacro.FindStringSubmatch(strings.TrimSpace(lines[idx]))
+	if !strings.EqualFold(match[1], core.Filter) {
+		return statement, nil
+	}
+
+	name := strings.TrimSpace(match[2])
+	expr, err := parser.ParseExpr(strings.Join(lines[idx+1:], "\n"))
+	if err != nil {
+		return "", fmt.Errorf("filter %s: %w", name, err)
+	}
+
+	// the table is found from the qualified columns
+	visitor := &columnVisitor{}
+	tree.WalkExprConst(visitor, expr)
+	tables := []string{}
+	for _, column := range visitor.names {
+		if column.NumParts > 1 && !slices.Contains(tables, column.Parts[1]) {
+			tables = append(tables, column.Parts[1])
+		}
+	}
+	if len(tables) != 1 {
+		return "", fmt.Errorf("filter %s: its columns have to be qualified with the name of a single table (e.g. users.deleted_at)", name)
+	}
+
+	for i := idx + 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		lines[i] = fmt.Sprintf("SELECT * FROM %s WHERE %s", tree.NameString(tables[0]), lines[i])
+		break
+	}
+	return strings.Join(lines, "\n"), nil
+}
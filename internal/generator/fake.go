@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Go predicates of the typed filters, evaluated by the in-memory fake client (cf fake.tmpl).
+// A filter without predicate is rejected by the fake
+var fakePredicates = map[string]string{
+	"Equal":              `return fakeEqual(column, arg)`,
+	"NotEqual":           `return !fakeIsNull(column) && !fakeEqual(column, arg)`,
+	"IsNull":             `return fakeIsNull(column)`,
+	"IsNotNull":          `return !fakeIsNull(column)`,
+	"In":                 `return fakeIn(column, args)`,
+	"NotIn":              `return !fakeIsNull(column) && !fakeIn(column, args)`,
+	"Like":               `return fakeLike(column, arg, false)`,
+	"NotLike":            `return !fakeIsNull(column) && !fakeLike(column, arg, false)`,
+	"ILike":              `return fakeLike(column, arg, true)`,
+	"NotILike":           `return !fakeIsNull(column) && !fakeLike(column, arg, true)`,
+	"StartsWith":         `return fakeLike(column, likeEscaper.Replace(arg)+"%", false)`,
+	"EndsWith":           `return fakeLike(column, "%"+likeEscaper.Replace(arg), false)`,
+	"ContainsText":       `return fakeLike(column, "%"+likeEscaper.Replace(arg)+"%", true)`,
+	"Regex":              `return fakeRegex(column, arg)`,
+	"GreaterThan":        "c, ok := fakeCompare(column, arg)\nreturn ok && c > 0",
+	"GreaterThanOrEqual": "c, ok := fakeCompare(column, arg)\nreturn ok && c >= 0",
+	"LesserThan":         "c, ok := fakeCompare(column, arg)\nreturn ok && c < 0",
+	"LesserThanOrEqual":  "c, ok := fakeCompare(column, arg)\nreturn ok && c <= 0",
+	"Between":            "low, ok := fakeCompare(column, from)\nhigh, ok2 := fakeCompare(column, to)\nreturn ok && ok2 && low >= 0 && high <= 0",
+	"HasKey":             "_, ok := fakeJSONLookup(column, path)\nreturn ok",
+	"PathEquals":         "v, ok := fakeJSONLookup(column, path)\nreturn ok && v != nil && fakeJSONText(v) == value",
+}
+
+// Expression recording the Go counterpart of a filter on the query builder (`filtered`)
+func getFakeFilter(model string, name string) string {
+	switch {
+	case name == "OrderAsc":
+		return `fakeOrderBy(filtered, f.field, false)`
+	case name == "OrderDesc":
+		return `fakeOrderBy(filtered, f.field, true)`
+	case name == "Contains" && model == FilterArrayField:
+		return fakeWhere(`return fakeArrayContains(column, args)`)
+	case name == "Contains" && model == FilterJSONField:
+		return fakeWhere(`return fakeJSONContains(column, arg)`)
+	}
+
+	if predicate, ok := fakePredicates[name]; ok {
+		return fakeWhere(predicate)
+	}
+	return ""
+}
+
+func fakeWhere(predicate string) string {
+	return "fakeWhere(filtered, f.field, func(column any) bool {\n" + predicate + "\n})"
+}
+
+type FakeUnique struct {
+	Name   string
+	Fields []*PostgresColumn
+}
+
+// Unique constraints enforced by the fake client, on top of the primary key
+func (table *PostgresTable) GetFakeUniques() []FakeUnique {
+	uniques := []FakeUnique{}
+	for _, constraint := range table.table.Constraints {
+		if constraint.Type != "UNIQUE" || slices.Equal(constraint.Columns, table.Primary) {
+			continue
+		}
+
+		fields := []*PostgresColumn{}
+		for _, name := range constraint.Columns {
+			for _, col := range table.Columns {
+				if col.Name == name {
+					fields = append(fields, col)
+				}
+			}
+		}
+		if len(fields) != len(constraint.Columns) {
+			continue
+		}
+
+		name := constraint.Name
+		if name == "" {
+			name = fmt.Sprintf("%s_%s_key", table.Name, strings.Join(constraint.Columns, "_"))
+		}
+		uniques = append(uniques, FakeUnique{Name: name, Fields: fields})
+	}
+	return uniques
+}
+
+// Assignment of a primary key generated by the database (serial or uuid default), simulated by the fake client
+func (table *PostgresTable) GetFakeIDInit() string {
+	if !table.HasIDAutoGenerated || table.HasCompositeID || len(table.ColumnIDs) != 1 {
+		return ""
+	}
+
+	col := table.ColumnIDs[0]
+	switch {
+	case strings.Contains(col.Type, "int"):
+		return fmt.Sprintf("row.%s = %s(r.seq)", col.NameNormalized, col.Type)
+	case col.Type == "uuid.UUID":
+		return fmt.Sprintf("row.%s = uuid.New()", col.NameNormalized)
+	case col.Type == "string":
+		return fmt.Sprintf("row.%s = fmt.Sprint(r.seq)", col.NameNormalized)
+	}
+	return ""
+}
+
+// Go expression of the current time (`now`) for a timestamp column, empty if the type is not supported
+func (col *PostgresColumn) GetFakeNow() string {
+	switch col.Type {
+	case "time.Time":
+		return "now"
+	case "*time.Time":
+		return "&now"
+	case "sql.NullTime":
+		return "sql.NullTime{Time: now, Valid: true}"
+	}
+	return ""
+}
+
+// Timestamp columns filled by the database on insert (created_at, updated_at)
+func (table *PostgresTable) GetFakeCreatedColumns() []*PostgresColumn {
+	return table.getFakeTimeColumns("created_at", "updated_at")
+}
+
+// Timestamp columns filled by the database on update (updated_at)
+func (table *PostgresTable) GetFakeUpdatedColumns() []*PostgresColumn {
+	return table.getFakeTimeColumns("updated_at")
+}
+
+// Timestamp column set by DeleteSoft (deleted_at)
+func (table *PostgresTable) GetFakeDeletedColumns() []*PostgresColumn {
+	return table.getFakeTimeColumns("deleted_at")
+}
+
+func (table *PostgresTable) getFakeTimeColumns(names ...string) []*PostgresColumn {
+	columns := []*PostgresColumn{}
+	for _, col := range table.Columns {
+		if slices.Contains(names, col.Name) && col.GetFakeNow() != "" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"fmt"
 	"slices"
 	"strings"
 
@@ -11,11 +12,16 @@ const (
 	FilterNumericField = "FilterNumericField"
 	FilterStringField  = "FilterStringField"
 	FilterArrayField   = "FilterArrayField"
+	FilterJSONField    = "FilterJSONField"
 	FilterGenericField = "FilterGenericField"
 )
 
 func GetNormalizedTypeFilter(col *PostgresColumn) string {
 	switch {
+	case col.TypeSQL == "TSVECTOR":
+		return FilterGenericField
+	case col.TypeSQL == "JSON", col.TypeSQL == "JSONB":
+		return FilterJSONField
 	case strings.HasPrefix(col.Type, "[]"):
 		return FilterArrayField
 	case strings.Contains(strings.ToLower(col.Type), "string"):
@@ -42,6 +48,112 @@ func (table *PostgresTable) GetFieldFilters() []SelectFieldFilter {
 	return filters
 }
 
+// Name of the full-text search filter, renamed if a column already use it
+func (table *PostgresTable) GetSearchMethod() string {
+	for _, col := range table.Columns {
+		if col.NameNormalized == "Search" {
+			return "FullTextSearch"
+		}
+	}
+	return "Search"
+}
+
+// Methods of the table filters, which cannot be used by a named filter
+var reservedFilterMethods = []string{"Offset", "Limit", "Distinct", "ForUpdate", "ForShare", "SkipLocked", "NoWait"}
+
+// Add the named filters (`-- filter: Active`) to the filters of their table (db.User.Query.Active()),
+// a filter named like a column or another filter method is an error
+func bindNamedFilters(tables []*PostgresTable, filters []core.SQLQuery) error {
+	for _, filter := range filters {
+		idx := slices.IndexFunc(tables, func(table *PostgresTable) bool {
+			return table.Name == filter.Table
+		})
+		if idx < 0 {
+			return fmt.Errorf("filter %s: unknown table %s", filter.Name, filter.Table)
+		}
+		table := tables[idx]
+		entry := toPostgresQuery(&filter)
+
+		used := slices.Clone(reservedFilterMethods)
+		if table.GetSearchSQL() != "" {
+			used = append(used, table.GetSearchMethod())
+		}
+		for _, col := range table.Columns {
+			used = append(used, col.NameNormalized)
+		}
+		for _, named := range table.NamedFilters {
+			used = append(used, named.NameNormalized)
+		}
+		if slices.Contains(used, entry.NameNormalized) {
+			return fmt.Errorf("filter %s: %sFilters already has a %s field or method", filter.Name, table.NameNormalized, entry.NameNormalized)
+		}
+
+		table.NamedFilters = append(table.NamedFilters, entry)
+	}
+	return nil
+}
+
+// SQL condition of the full-text search filter, based on the search sources supported by the driver
+func (table *PostgresTable) GetSearchSQL() string {
+	conditions := []string{}
+	for _, search := range table.table.Searches {
+		switch {
+		case search.Type == core.SearchFTS5 && table.driver == core.DriverSqlite:
+			conditions = append(conditions, fmt.Sprintf("%s.%s IN (SELECT rowid FROM %s WHERE %s MATCH ?)", table.Name, search.RowID, search.Name, search.Name))
+		case search.Type == core.SearchFulltext && (table.driver == core.DriverMysql || table.driver == core.DriverMariaDB):
+			columns := []string{}
+			for _, column := range search.Columns {
+				columns = append(columns, fmt.Sprintf("%s.%s", table.Name, column))
+			}
+			conditions = append(conditions, fmt.Sprintf("MATCH (%s) AGAINST (? IN NATURAL LANGUAGE MODE)", strings.Join(columns, ", ")))
+		case search.Type == core.SearchTsvector && search.Expr != "" && table.isPostgres():
+			config := ""
+			if search.Config != "" {
+				config = search.Config + ", "
+			}
+			conditions = append(conditions, fmt.Sprintf("%s @@ plainto_tsquery(%s?)", search.Expr, config))
+		case search.Type == core.SearchTsvector && table.isPostgres():
+			conditions = append(conditions, fmt.Sprintf("%s.%s @@ plainto_tsquery(?)", table.Name, search.Columns[0]))
+		}
+	}
+
+	if len(conditions) <= 1 {
+		return strings.Join(conditions, "")
+	}
+	return "(" + strings.Join(conditions, ") OR (") + ")"
+}
+
+// Arguments of the full-text search filter (the same query is used by each search source)
+func (table *PostgresTable) GetSearchArgs() string {
+	return strings.TrimSuffix(strings.Repeat("query, ", strings.Count(table.GetSearchSQL(), "?")), ", ")
+}
+
+// Row locking clause for the driver, sqlite has no row level lock (a write transaction locks the whole database)
+func (table *PostgresTable) GetRowLock(kind string) string {
+	if table.driver == core.DriverSqlite {
+		return ""
+	}
+
+	switch kind {
+	case "update":
+		return "FOR UPDATE"
+	case "share":
+		if table.driver == core.DriverMariaDB {
+			return "LOCK IN SHARE MODE"
+		}
+		return "FOR SHARE"
+	case "skip":
+		return "SKIP LOCKED"
+	case "nowait":
+		return "NOWAIT"
+	}
+	return ""
+}
+
+func (table *PostgresTable) isPostgres() bool {
+	return table.driver != core.DriverSqlite && table.driver != core.DriverMysql && table.driver != core.DriverMariaDB
+}
+
 func GetFilterMethods(tables []*PostgresTable, driver string) []FilterMethod {
 	supportedFilters := []string{"FilterGenericField"}
 	for _, table := range tables {
@@ -60,9 +172,14 @@ func GetFilterMethods(tables []*PostgresTable, driver string) []FilterMethod {
 
 		addFiltersIn(driver, t, &method)
 		addFiltersCompare(t, &method)
-		addFiltersLike(t, &method)
+		addFiltersLike(driver, t, &method)
 		addFiltersMathCompare(t, &method)
 		addFiltersArray(t, &method)
+		addFiltersJSON(driver, t, &method)
+
+		for i, filter := range method.Filters {
+			method.Filters[i].Fake = getFakeFilter(t, filter.Name)
+		}
 
 		methods = append(methods, method)
 	}
@@ -150,6 +267,108 @@ func addFiltersArray(t string, method *FilterMethod) {
 	)
 }
 
+func addFiltersJSON(driver string, t string, method *FilterMethod) {
+	if t != FilterJSONField {
+		return
+	}
+
+	switch driver {
+	case core.DriverSqlite:
+		method.Filters = append(method.Filters,
+			SelectFilter{
+				Model: t,
+				Name:  "HasKey",
+				Pre: `sql := fmt.Sprintf("json_type(%s.%s, ?) IS NOT NULL", f.table, f.field)
+				jsonPath := "$.\"" + strings.ReplaceAll(path, ".", "\".\"") + "\""
+				`,
+				Comment: `Only include Records with a json field containing a key (nested keys are separated by a dot)`,
+				SQL:     `.Where(sql, jsonPath)`,
+				Args:    []string{"path string"},
+			},
+			SelectFilter{
+				Model: t,
+				Name:  "PathEquals",
+				Pre: `sql := fmt.Sprintf("CAST(json_extract(%s.%s, ?) AS TEXT) = ?", f.table, f.field)
+				jsonPath := "$.\"" + strings.ReplaceAll(path, ".", "\".\"") + "\""
+				`,
+				Comment: `Only include Records with a json field value at a path equal to a specific text (nested keys are separated by a dot)`,
+				SQL:     `.Where(sql, jsonPath, value)`,
+				Args:    []string{"path string", "value string"},
+			},
+		)
+	case core.DriverMysql, core.DriverMariaDB:
+		method.Filters = append(method.Filters,
+			SelectFilter{
+				Model: t,
+				Name:  "HasKey",
+				Pre: `sql := fmt.Sprintf("JSON_CONTAINS_PATH(%s.%s, 'one', ?)", f.table, f.field)
+				jsonPath := "$.\"" + strings.ReplaceAll(path, ".", "\".\"") + "\""
+				`,
+				Comment: `Only include Records with a json field containing a key (nested keys are separated by a dot)`,
+				SQL:     `.Where(sql, jsonPath)`,
+				Args:    []string{"path string"},
+			},
+			SelectFilter{
+				Model: t,
+				Name:  "PathEquals",
+				Pre: `sql := fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s.%s, ?)) = ?", f.table, f.field)
+				jsonPath := "$.\"" + strings.ReplaceAll(path, ".", "\".\"") + "\""
+				`,
+				Comment: `Only include Records with a json field value at a path equal to a specific text (nested keys are separated by a dot)`,
+				SQL:     `.Where(sql, jsonPath, value)`,
+				Args:    []string{"path string", "value string"},
+			},
+			SelectFilter{
+				Model: t,
+				Name:  "Contains",
+				Pre: `data, err := json.Marshal(arg)
+				if err != nil {
+					return f.IsNull()
+				}
+				sql := fmt.Sprintf("JSON_CONTAINS(%s.%s, ?)", f.table, f.field)
+				`,
+				Comment: `Only include Records with a json field containing a specific json document`,
+				SQL:     `.Where(sql, string(data))`,
+				Args:    []string{"arg any"},
+			},
+		)
+	default:
+		method.Filters = append(method.Filters,
+			SelectFilter{
+				Model: t,
+				Name:  "HasKey",
+				Pre: `sql := fmt.Sprintf("%s.%s::jsonb #> ?::text[] IS NOT NULL", f.table, f.field)
+				`,
+				Comment: `Only include Records with a json field containing a key (nested keys are separated by a dot)`,
+				SQL:     `.Where(sql, pq.Array(strings.Split(path, ".")))`,
+				Args:    []string{"path string"},
+			},
+			SelectFilter{
+				Model: t,
+				Name:  "PathEquals",
+				Pre: `sql := fmt.Sprintf("%s.%s::jsonb #>> ?::text[] = ?", f.table, f.field)
+				`,
+				Comment: `Only include Records with a json field value at a path equal to a specific text (nested keys are separated by a dot)`,
+				SQL:     `.Where(sql, pq.Array(strings.Split(path, ".")), value)`,
+				Args:    []string{"path string", "value string"},
+			},
+			SelectFilter{
+				Model: t,
+				Name:  "Contains",
+				Pre: `data, err := json.Marshal(arg)
+				if err != nil {
+					return f.IsNull()
+				}
+				sql := fmt.Sprintf("%s.%s::jsonb @> ?::jsonb", f.table, f.field)
+				`,
+				Comment: `Only include Records with a json field containing a specific json document`,
+				SQL:     `.Where(sql, string(data))`,
+				Args:    []string{"arg any"},
+			},
+		)
+	}
+}
+
 func addFiltersMathCompare(t string, method *FilterMethod) {
 	if t != "FilterNumericField" {
 		return
@@ -204,11 +423,35 @@ func addFiltersMathCompare(t string, method *FilterMethod) {
 	)
 }
 
-func addFiltersLike(t string, method *FilterMethod) {
+func addFiltersLike(driver string, t string, method *FilterMethod) {
 	if t != "FilterStringField" {
 		return
 	}
 
+	ilike := `sql := fmt.Sprintf("%s.%s ILIKE ?", f.table, f.field)
+			`
+	notILike := `sql := fmt.Sprintf("%s.%s NOT ILIKE ?", f.table, f.field)
+			`
+	regex := `sql := fmt.Sprintf("%s.%s ~ ?", f.table, f.field)
+			`
+	contains := `sql := fmt.Sprintf("%s.%s ILIKE ?", f.table, f.field)
+			`
+	escape := ""
+	if driver == core.DriverSqlite || driver == core.DriverMysql || driver == core.DriverMariaDB {
+		ilike = `sql := fmt.Sprintf("LOWER(%s.%s) LIKE LOWER(?)", f.table, f.field)
+			`
+		notILike = `sql := fmt.Sprintf("LOWER(%s.%s) NOT LIKE LOWER(?)", f.table, f.field)
+			`
+		regex = `sql := fmt.Sprintf("%s.%s REGEXP ?", f.table, f.field)
+			`
+		contains = ilike
+	}
+	if driver == core.DriverSqlite {
+		escape = ` ESCAPE '\\'`
+		contains = `sql := fmt.Sprintf("LOWER(%s.%s) LIKE LOWER(?) ESCAPE '\\'", f.table, f.field)
+			`
+	}
+
 	method.Filters = append(method.Filters,
 		SelectFilter{
 			Model: t,
@@ -228,6 +471,56 @@ func addFiltersLike(t string, method *FilterMethod) {
 			SQL:     `.Where(sql, arg)`,
 			Args:    []string{"arg T"},
 		},
+		SelectFilter{
+			Model:   t,
+			Name:    "ILike",
+			Pre:     ilike,
+			Comment: `Only include Records with a field contains a specific value, case insensitive (use % as wildcard)`,
+			SQL:     `.Where(sql, arg)`,
+			Args:    []string{"arg T"},
+		},
+		SelectFilter{
+			Model:   t,
+			Name:    "NotILike",
+			Pre:     notILike,
+			Comment: `Exclude Records with a field contains a specific value, case insensitive (use % as wildcard)`,
+			SQL:     `.Where(sql, arg)`,
+			Args:    []string{"arg T"},
+		},
+		SelectFilter{
+			Model: t,
+			Name:  "StartsWith",
+			Pre: `sql := fmt.Sprintf("%s.%s LIKE ?` + escape + `", f.table, f.field)
+			`,
+			Comment: `Only include Records with a field starting with a specific value (wildcards are escaped)`,
+			SQL:     `.Where(sql, likeEscaper.Replace(arg)+"%")`,
+			Args:    []string{"arg string"},
+		},
+		SelectFilter{
+			Model: t,
+			Name:  "EndsWith",
+			Pre: `sql := fmt.Sprintf("%s.%s LIKE ?` + escape + `", f.table, f.field)
+			`,
+			Comment: `Only include Records with a field ending with a specific value (wildcards are escaped)`,
+			SQL:     `.Where(sql, "%"+likeEscaper.Replace(arg))`,
+			Args:    []string{"arg string"},
+		},
+		SelectFilter{
+			Model:   t,
+			Name:    "ContainsText",
+			Pre:     contains,
+			Comment: `Only include Records with a field containing a specific text, case insensitive (wildcards are escaped)`,
+			SQL:     `.Where(sql, "%"+likeEscaper.Replace(arg)+"%")`,
+			Args:    []string{"arg string"},
+		},
+		SelectFilter{
+			Model:   t,
+			Name:    "Regex",
+			Pre:     regex,
+			Comment: `Only include Records with a field matching a regular expression`,
+			SQL:     `.Where(sql, arg)`,
+			Args:    []string{"arg string"},
+		},
 	)
 }
 
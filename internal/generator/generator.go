@@ -5,12 +5,14 @@ import (
 	"bytes"
 	"embed"
 	"fmt"
+	"go/token"
 	"io"
 	"slices"
 	"strconv"
 	"strings"
 	"text/template"
 	"time"
+	"unicode"
 
 	"github.com/gertd/go-pluralize"
 	"github.com/iancoleman/strcase"
@@ -35,7 +37,7 @@ var customTypes = []FieldInitializer{
 }
 
 //nolint:funlen,gocognit,gocyclo,cyclop // Need refactoring
-func Generate(schema *core.SQLSchema, contents io.Writer, pkg string, driver string, logger string) error {
+func Generate(schema *core.SQLSchema, contents io.Writer, pkg string, driver string, logger string, fake bool, factories bool, notify bool, groupQueries bool) error {
 	deps := map[string]string{}
 	var templateType string
 	switch driver {
@@ -79,6 +81,31 @@ func Generate(schema *core.SQLSchema, contents io.Writer, pkg string, driver str
 		return err
 	}
 
+	batchTmpl, err := template.ParseFS(templates, "templates/batch.tmpl")
+	if err != nil {
+		return err
+	}
+
+	notifyTmpl, err := template.ParseFS(templates, "templates/notify.tmpl")
+	if err != nil {
+		return err
+	}
+
+	repositoryTmpl, err := template.ParseFS(templates, "templates/repository.tmpl")
+	if err != nil {
+		return err
+	}
+
+	fakeTmpl, err := template.ParseFS(templates, "templates/fake.tmpl")
+	if err != nil {
+		return err
+	}
+
+	testFactoryTmpl, err := template.ParseFS(templates, "templates/testfactory.tmpl")
+	if err != nil {
+		return err
+	}
+
 	tables := maps.Values(schema.Tables)
 	slices.SortFunc(tables, func(i, j *core.SQLTable) int {
 		return i.Order - j.Order
@@ -98,6 +125,15 @@ func Generate(schema *core.SQLSchema, contents io.Writer, pkg string, driver str
 		postgresQueries = append(postgresQueries, entry)
 	}
 
+	if err = bindNamedFilters(postgresTables, schema.Filters); err != nil {
+		return err
+	}
+
+	customQueries := postgresQueries
+	if groupQueries {
+		customQueries = attachQueries(postgresTables, postgresQueries)
+	}
+
 	for _, t := range postgresTables {
 		for _, c := range t.Columns {
 			for _, custom := range customTypes {
@@ -114,6 +150,33 @@ func Generate(schema *core.SQLSchema, contents io.Writer, pkg string, driver str
 		}
 	}
 
+	hasJSONType := bindJSONTypes(postgresTables, postgresQueries, driver, deps)
+	if hasJSONType {
+		deps["driver"] = "database/sql/driver"
+	}
+
+	// the row locks are recorded on the query builder
+	deps["builder"] = "github.com/lann/builder"
+
+	if fake {
+		deps["driver"] = "database/sql/driver"
+		deps["reflect"] = "reflect"
+		deps["regexp"] = "regexp"
+		deps["time"] = timeDeps
+	}
+
+	// LISTEN / NOTIFY helpers, a listener acquires its own connection from a pool
+	hasNotify := notify && driver == "pgx"
+	if hasNotify {
+		deps["pgxpool"] = "github.com/jackc/pgx/v5/pgxpool"
+	}
+
+	if factories {
+		deps["reflect"] = "reflect"
+		deps["rand"] = "math/rand/v2"
+		deps["time"] = timeDeps
+	}
+
 	var ctxConstBuf bytes.Buffer
 	ctxConst := bufio.NewWriter(&ctxConstBuf)
 	ctxConst.Flush()
@@ -123,10 +186,6 @@ func Generate(schema *core.SQLSchema, contents io.Writer, pkg string, driver str
 		placeholder = "squirrel.Question"
 	}
 
-	if driver == core.DriverMysql || driver == core.DriverMariaDB {
-		deps["strings"] = "strings"
-	}
-
 	logConfig := LoggerConfig{}
 
 	switch logger {
@@ -164,21 +223,26 @@ func Generate(schema *core.SQLSchema, contents io.Writer, pkg string, driver str
 		Version:     "0.0.1",
 		Deps:        maps.Values(deps),
 		Placeholder: placeholder,
+		HasJSONType: hasJSONType,
 	}); err != nil {
 		return err
 	}
 
 	_, ok := deps["uuid.UUID"]
 	if err = factoryTmpl.Execute(contents, struct {
-		Tables  []*PostgresTable
-		Queries []*PostgresQuery
-		Filters []FilterMethod
-		Logger  LoggerConfig
+		Tables    []*PostgresTable
+		Queries   []*PostgresQuery
+		Filters   []FilterMethod
+		Logger    LoggerConfig
+		HasFake   bool
+		HasNotify bool
 	}{
-		Tables:  postgresTables,
-		Queries: postgresQueries,
-		Filters: GetFilterMethods(postgresTables, driver),
-		Logger:  logConfig,
+		Tables:    postgresTables,
+		Queries:   customQueries,
+		Filters:   GetFilterMethods(postgresTables, driver),
+		Logger:    logConfig,
+		HasFake:   fake,
+		HasNotify: hasNotify,
 	}); err != nil {
 		return err
 	}
@@ -209,21 +273,125 @@ func Generate(schema *core.SQLSchema, contents io.Writer, pkg string, driver str
 		}); err != nil {
 			return err
 		}
+
+		if driver == "pgx" {
+			if err = batchTmpl.Execute(contents, struct {
+				Table *PostgresTable
+			}{
+				Table: table,
+			}); err != nil {
+				return err
+			}
+
+			if table.View || !hasNotify {
+				continue
+			}
+
+			if err = notifyTmpl.Execute(contents, struct {
+				Table *PostgresTable
+			}{
+				Table: table,
+			}); err != nil {
+				return err
+			}
+		}
 	}
 
 	if err = customQueriesTmpl.Execute(contents, struct {
-		Queries []*PostgresQuery
-		Logger  LoggerConfig
+		Queries    []*PostgresQuery
+		Logger     LoggerConfig
+		HasBatch   bool
+		HasCustom  bool
+		HasDerived bool
 	}{
-		Queries: postgresQueries,
-		Logger:  logConfig,
+		Queries:    postgresQueries,
+		Logger:     logConfig,
+		HasBatch:   driver == "pgx",
+		HasCustom:  len(customQueries) > 0,
+		HasDerived: slices.ContainsFunc(postgresQueries, func(query *PostgresQuery) bool { return query.Derived }),
 	}); err != nil {
 		return err
 	}
 
+	if err = repositoryTmpl.Execute(contents, struct {
+		Tables    []*PostgresTable
+		Queries   []*PostgresQuery
+		HasNotify bool
+	}{
+		Tables:    postgresTables,
+		Queries:   customQueries,
+		HasNotify: hasNotify,
+	}); err != nil {
+		return err
+	}
+
+	if fake {
+		if err = fakeTmpl.Execute(contents, struct {
+			Tables    []*PostgresTable
+			Queries   []*PostgresQuery
+			HasNotify bool
+		}{
+			Tables:    postgresTables,
+			Queries:   customQueries,
+			HasNotify: hasNotify,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if factories {
+		if err = testFactoryTmpl.Execute(contents, struct {
+			Tables []*PostgresTable
+		}{
+			Tables: postgresTables,
+		}); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// Json columns bound to a user type need their package imported.
+// pgx (un)marshal json values natively, database/sql drivers go through the JSON[T] wrapper
+func bindJSONTypes(tables []*PostgresTable, queries []*PostgresQuery, driver string, deps map[string]string) bool {
+	columns := []*PostgresColumn{}
+	for _, t := range tables {
+		columns = append(columns, t.Columns...)
+		columns = append(columns, t.ColumnIDs...)
+		columns = append(columns, t.ColumnsCreate...)
+		columns = append(columns, t.ColumnsUpdate...)
+	}
+	for _, q := range queries {
+		columns = append(columns, q.Fields...)
+	}
+
+	found := false
+	for _, c := range columns {
+		if c.TypeGo == "" {
+			continue
+		}
+		found = true
+
+		if _, importPath := parseGoType(c.TypeGo); importPath != "" {
+			deps[importPath] = importPath
+		}
+
+		if driver != "pgx" {
+			c.Type = wrapJSONType(c.Type)
+		}
+	}
+
+	return found && driver != "pgx"
+}
+
+func wrapJSONType(goType string) string {
+	if strings.HasPrefix(goType, "*") {
+		return "*JSON[" + goType[1:] + "]"
+	}
+	return "JSON[" + goType + "]"
+}
+
 func toPostgresQuery(query *core.SQLQuery) *PostgresQuery {
 	fields := []*PostgresColumn{}
 	slices.SortFunc(query.SelectFields, func(i, j *core.SQLColumn) int {
@@ -233,12 +401,17 @@ func toPostgresQuery(query *core.SQLQuery) *PostgresQuery {
 		fields = append(fields, toPostgresColumn(field))
 	}
 
-	return &PostgresQuery{
+	params := toQueryParams(query)
+
+	entry := &PostgresQuery{
 		Name:           query.Name,
 		NameNormalized: strcase.ToCamel(query.Name),
+		MethodName:     strcase.ToCamel(query.Name),
+		Comment:        query.Comment,
 		Method:         query.Method,
 		Fields:         fields,
 		SQLQuery:       query.Query,
+		Table:          query.Table,
 
 		Select:         query.Select,
 		SelectOriginal: query.SelectOriginal,
@@ -246,7 +419,304 @@ func toPostgresQuery(query *core.SQLQuery) *PostgresQuery {
 		Where:          query.Where,
 		GroupBy:        query.GroupBy,
 		Having:         query.Having,
+		Distinct:       query.Distinct,
+		DistinctOn:     query.DistinctOn,
+		OrderBy:        query.OrderBy,
+		Limit:          toLimitValue(query.Limit),
+		Offset:         toLimitValue(query.Offset),
+
+		Params:       params,
+		WhereParams:  toParamNames(query.WhereParams),
+		HavingParams: toParamNames(query.HavingParams),
+
+		Statement:     query.Statement,
+		StatementArgs: toStatementArgs(query),
+		Derived:       query.Derived,
+	}
+	bindNestedFields(entry, query)
+
+	return entry
+}
+
+// Table client methods, which cannot be used by an attached query
+var reservedTableMethods = []string{
+	"New", "Filters", "Insert", "InsertMany", "BulkInsert", "Upsert", "UpsertMany", "Update", "UpdateMany",
+	"DeleteSoft", "DeleteHard", "Count", "FindMany", "FindUnique", "Refresh", "ListenChanges", "NotifyChange", "Query",
+}
+
+// Attach the custom queries to the client of their main table (db.User.NotDeleted() for UserNotDeleted),
+// the method is named without the table prefix (User or Users) when it does not conflict with another method.
+// The queries on other tables (e.g. CTEs) stay in db.Queries, and are returned
+func attachQueries(tables []*PostgresTable, queries []*PostgresQuery) []*PostgresQuery {
+	custom := []*PostgresQuery{}
+	for _, query := range queries {
+		idx := slices.IndexFunc(tables, func(table *PostgresTable) bool {
+			return table.Name == query.Table
+		})
+		if idx < 0 {
+			custom = append(custom, query)
+			continue
+		}
+
+		table := tables[idx]
+		used := slices.Clone(reservedTableMethods)
+		for _, method := range table.GetSelectPrimarySQL() {
+			used = append(used, method.Method)
+		}
+		for _, attached := range table.Queries {
+			used = append(used, attached.MethodName)
+		}
+
+		query.MethodName = query.NameNormalized
+		for _, prefix := range []string{strcase.ToCamel(table.Name), table.NameNormalized} {
+			name := strings.TrimPrefix(query.NameNormalized, prefix)
+			if name != query.NameNormalized && name != "" && unicode.IsUpper(rune(name[0])) && !slices.Contains(used, name) {
+				query.MethodName = name
+				break
+			}
+		}
+		query.Client = table.NameNormalized
+		table.Queries = append(table.Queries, query)
+	}
+	return custom
+}
+
+// Go doc comment of a SQL comment (COMMENT ON, or the comment lines above a query)
+func docComment(comment string) string {
+	return "// " + strings.ReplaceAll(comment, "\n", "\n// ")
+}
+
+func (table *PostgresTable) GetDocComment() string {
+	return docComment(table.Comment)
+}
+
+func (column *PostgresColumn) GetDocComment() string {
+	return docComment(column.Comment)
+}
+
+func (query *PostgresQuery) GetDocComment() string {
+	if query.Comment == "" {
+		return docComment(query.GetDescription())
 	}
+	return docComment(query.Comment)
+}
+
+// Client of the query, the receiver of its method (e.g. User for UserQueries)
+func (query *PostgresQuery) GetClient() string {
+	if query.Client != "" {
+		return query.Client
+	}
+	return "Custom"
+}
+
+// Field of the client in DBClient (e.g. db.User, db.Queries)
+func (query *PostgresQuery) GetClientField() string {
+	if query.Client != "" {
+		return query.Client
+	}
+	return "Queries"
+}
+
+// Split the fields of a nested query between the parent and the nested rows, with the Go expressions
+// used to build them from a flat row (cf SQLQueryNest)
+func bindNestedFields(entry *PostgresQuery, query *core.SQLQuery) {
+	if len(query.Nest) == 0 {
+		return
+	}
+
+	rowFields := map[string]*PostgresColumn{}
+	nested := []string{}
+	for i, field := range query.SelectFields {
+		rowFields[field.Name] = entry.Fields[i]
+	}
+
+	keys := []string{}
+	for _, name := range query.NestKeys {
+		keys = append(keys, "row."+rowFields[name].NameNormalized)
+	}
+	entry.NestKey = fmt.Sprintf("[%d]any{%s}", len(keys), strings.Join(keys, ", "))
+
+	for _, nest := range query.Nest {
+		n := &PostgresQueryNest{
+			Field: strcase.ToCamel(nest.Name),
+			JSON:  strings.ToLower(strcase.ToSnake(nest.Name)),
+			Type:  entry.NameNormalized + strcase.ToCamel(plural.Singular(nest.Name)),
+		}
+
+		for _, field := range nest.Fields {
+			col := toPostgresColumn(field)
+			row := rowFields[field.Name]
+			value := "row." + row.NameNormalized
+			if strings.HasPrefix(row.Type, "*") && !strings.HasPrefix(col.Type, "*") {
+				value = "*" + value
+			}
+			n.Fields = append(n.Fields, col)
+			n.Values = append(n.Values, value)
+			nested = append(nested, field.Name)
+		}
+
+		conditions := []string{}
+		keys := []string{strconv.Quote(nest.Name), "idx"}
+		for _, name := range nest.Keys {
+			row := rowFields[name]
+			if strings.HasPrefix(row.Type, "*") {
+				conditions = append(conditions, fmt.Sprintf("row.%s != nil", row.NameNormalized))
+				keys = append(keys, "*row."+row.NameNormalized)
+			} else {
+				keys = append(keys, "row."+row.NameNormalized)
+			}
+		}
+		n.Condition = strings.Join(conditions, " && ")
+		n.Key = fmt.Sprintf("[%d]any{%s}", len(keys), strings.Join(keys, ", "))
+
+		entry.Nest = append(entry.Nest, n)
+	}
+
+	for i, field := range query.SelectFields {
+		if !slices.Contains(nested, field.Name) {
+			entry.NestFields = append(entry.NestFields, entry.Fields[i])
+		}
+	}
+}
+
+// Go expression of the rows returned by a query (grouped with nest<Query> when nested)
+func (query *PostgresQuery) GetQueryRows() string {
+	if len(query.Nest) > 0 {
+		return fmt.Sprintf("nest%s(QueryMany[%sRow](q.ctx, sql, args...))", query.NameNormalized, query.NameNormalized)
+	}
+	return fmt.Sprintf("QueryMany[%sModel](q.ctx, sql, args...)", query.NameNormalized)
+}
+
+// Go expression limiting a queryOne to its first row, keeping the offset of the query
+func (query *PostgresQuery) GetLimitFirst() string {
+	if query.Offset != "" {
+		return "query.Limit(1)"
+	}
+	return "limitFirst(query)"
+}
+
+// Queries returning rows have a model (queryOne, queryMany)
+func (query *PostgresQuery) HasModel() bool {
+	return query.Method == core.QueryOne || query.Method == core.QueryMany
+}
+
+// Go type returned by the query method, on top of the error
+func (query *PostgresQuery) GetResultType() string {
+	switch query.Method {
+	case core.QueryOne:
+		return "*" + query.NameNormalized + "Model"
+	case core.Exec:
+		return ""
+	case core.ExecRows:
+		return "int64"
+	}
+	return "[]" + query.NameNormalized + "Model"
+}
+
+// Values returned with an error by the query method
+func (query *PostgresQuery) GetZeroResult() string {
+	switch query.Method {
+	case core.Exec:
+		return ""
+	case core.ExecRows:
+		return "0, "
+	}
+	return "nil, "
+}
+
+func (query *PostgresQuery) GetDescription() string {
+	if query.Statement != "" {
+		switch query.Method {
+		case core.QueryOne:
+			return fmt.Sprintf("Execute the %s query, and return its first row (ErrNotFound if there is none)", query.NameNormalized)
+		case core.QueryMany:
+			return fmt.Sprintf("Execute the %s query, and return its rows", query.NameNormalized)
+		}
+	}
+
+	switch query.Method {
+	case core.Filter:
+		return fmt.Sprintf("Only include Records matching %s", query.Where)
+	case core.QueryOne:
+		return fmt.Sprintf("Find one %s record based on the provided conditions (ErrNotFound if there is none)", query.NameNormalized)
+	case core.Exec:
+		return fmt.Sprintf("Execute the %s query", query.NameNormalized)
+	case core.ExecRows:
+		return fmt.Sprintf("Execute the %s query, and return the number of affected rows", query.NameNormalized)
+	}
+	return fmt.Sprintf("Find %s records based on the provided conditions", query.NameNormalized)
+}
+
+func (query *PostgresQuery) GetUsageResult() string {
+	switch query.Method {
+	case core.QueryOne:
+		return "entity, err"
+	case core.Exec:
+		return "err"
+	case core.ExecRows:
+		return "count, err"
+	}
+	return "entities, err"
+}
+
+// Go arguments of the query parameters (cf ParseQueries)
+func toQueryParams(query *core.SQLQuery) []*PostgresColumn {
+	params := []*PostgresColumn{}
+	for _, param := range query.Params {
+		col := toPostgresColumn(param)
+		col.Name = toParamName(param.Name)
+		params = append(params, col)
+	}
+	return params
+}
+
+// Names already used by the generated query methods, or reserved by Go
+var reservedParamNames = []string{"q", "query", "filters", "filter", "cond", "sql", "args", "err", "start", "requestData", "requestErr"}
+
+func toParamName(name string) string {
+	param := strcase.ToLowerCamel(name)
+	if token.IsKeyword(param) || slices.Contains(reservedParamNames, param) {
+		return param + "Arg"
+	}
+	return param
+}
+
+func toParamNames(names []string) []string {
+	params := []string{}
+	for _, name := range names {
+		params = append(params, toParamName(name))
+	}
+	return params
+}
+
+// A limit or offset is a number, or the name of a parameter of the query method
+func toLimitValue(value string) string {
+	if _, err := strconv.ParseUint(value, 10, 64); err == nil || value == "" {
+		return value
+	}
+	return toParamName(value)
+}
+
+// Write queries take their parameters as a struct (cf <Query>Params)
+func toParamFields(names []string) []string {
+	fields := []string{}
+	for _, name := range names {
+		fields = append(fields, strcase.ToCamel(name))
+	}
+	return fields
+}
+
+// Arguments of a statement, read queries take them positionally like any other select
+func toStatementArgs(query *core.SQLQuery) []string {
+	if query.Derived {
+		return toParamNames(query.StatementParams)
+	}
+
+	args := []string{}
+	for _, field := range toParamFields(query.StatementParams) {
+		args = append(args, "params."+field)
+	}
+	return args
 }
 
 func toPostgresTable(table *core.SQLTable, driver string) *PostgresTable {
@@ -271,6 +741,7 @@ func toPostgresTable(table *core.SQLTable, driver string) *PostgresTable {
 
 		Name:               table.Name,
 		NameNormalized:     strcase.ToCamel(plural.Singular(table.Name)),
+		Comment:            table.Comment,
 		Columns:            columns,
 		HasCompositeID:     len(getPrimaryFields(table)) > 1,
 		HasIDAutoGenerated: isIDGenerated(table),
@@ -278,6 +749,8 @@ func toPostgresTable(table *core.SQLTable, driver string) *PostgresTable {
 		ColumnsCreate:      create,
 		ColumnsUpdate:      update,
 		Primary:            getPrimaryFields(table),
+		View:               table.View,
+		Materialized:       table.Materialized,
 	}
 }
 
@@ -300,9 +773,13 @@ func toPostgresColumn(column *core.SQLColumn) *PostgresColumn {
 		NameJSON:       json,
 		Type:           val,
 		TypeSQL:        column.TypeSQL,
+		TypeGo:         column.TypeGo,
 		Nullable:       column.Nullable,
 		IsArray:        strings.Contains(column.TypeSQL, "[]"),
 		HasDefault:     column.HasDefault,
+		Length:         column.Length,
+		Enum:           column.Enum,
+		Comment:        column.Comment,
 	}
 }
 
@@ -415,6 +892,14 @@ func getAutogeneratedFields() []string {
 	return []string{"created_at", "updated_at", "deleted_at"}
 }
 
+// Fields of the Create input, which depend on the primary key (cf model.tmpl)
+func (table *PostgresTable) GetCreateInputColumns() []*PostgresColumn {
+	if table.HasCompositeID {
+		return table.ColumnsUpdate
+	}
+	return table.ColumnsCreate
+}
+
 func (table *PostgresTable) HasStructCopy() bool {
 	return len(table.ColumnsCreate) == len(table.ColumnsUpdate)
 }
@@ -475,6 +960,13 @@ func (table *PostgresTable) HasInsertReturning() bool {
 	return table.driver != core.DriverMysql
 }
 
+func (table *PostgresTable) getCreateColumns() []*PostgresColumn {
+	if table.HasIDAutoGenerated {
+		return table.ColumnsCreate
+	}
+	return table.ColumnsUpdate
+}
+
 func (table *PostgresTable) GetCreateSQLContent() string {
 	fields := []string{}
 	keys := []string{}
@@ -484,12 +976,7 @@ func (table *PostgresTable) GetCreateSQLContent() string {
 		fields = append(fields, val.Name)
 	}
 
-	columns := table.ColumnsUpdate
-	if table.HasIDAutoGenerated {
-		columns = table.ColumnsCreate
-	}
-
-	for id, val := range columns {
+	for id, val := range table.getCreateColumns() {
 		keys = append(keys, val.Name)
 		values = append(values, param(id+1, table.driver))
 	}
@@ -511,6 +998,39 @@ func (table *PostgresTable) GetCreateSQLContent() string {
 	return fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s) RETURNING %s;`, table.Name, strings.Join(keys, ", "), strings.Join(values, ", "), strings.Join(fields, ", "))
 }
 
+func (table *PostgresTable) HasCopyFrom() bool {
+	return table.driver == "pgx"
+}
+
+// Columns filled by BulkInsert, in the same order than the values of Insert
+func (table *PostgresTable) GetCopyFromColumns() string {
+	keys := []string{}
+	for _, val := range table.getCreateColumns() {
+		keys = append(keys, strconv.Quote(val.Name))
+	}
+
+	if table.HasInsertExtraCreated() {
+		keys = append(keys, strconv.Quote("created_at"))
+	}
+
+	if table.HasInsertExtraUpdated() {
+		keys = append(keys, strconv.Quote("updated_at"))
+	}
+
+	return strings.Join(keys, ", ")
+}
+
+const defaultChunkSize = 250
+
+// Default number of rows per chunk of InsertMany, UpsertMany and UpdateMany.
+// MySQL binds every value of an inserted chunk, which has to stay under its limit of 65535 parameters
+func (table *PostgresTable) GetChunkSize() int {
+	if !table.IsInsertMany() {
+		return defaultChunkSize
+	}
+	return max(1, min(defaultChunkSize, 65535/max(1, len(table.Columns))))
+}
+
 func (table *PostgresTable) IsInsertMany() bool {
 	return table.driver == core.DriverMariaDB || table.driver == core.DriverMysql
 }
@@ -889,6 +1409,9 @@ func (table *PostgresTable) GetDeleteHardSQLContent() string {
 
 func (table *PostgresTable) GetSelectPrimarySQL() []SelectQuery {
 	entries := []SelectQuery{}
+	if table.View {
+		return entries
+	}
 
 	entries = append(entries, SelectQuery{
 		Name:   table.NameNormalized,
@@ -13,6 +13,7 @@ type PostgresTable struct {
 
 	Name               string
 	NameNormalized     string
+	Comment            string
 	HasCompositeID     bool
 	HasIDAutoGenerated bool
 	ColumnIDs          []*PostgresColumn
@@ -20,6 +21,16 @@ type PostgresTable struct {
 	ColumnsCreate      []*PostgresColumn
 	ColumnsUpdate      []*PostgresColumn
 	Primary            []string
+
+	// Read-only table (CREATE VIEW), a materialized view can be refreshed
+	View         bool
+	Materialized bool
+
+	// Custom queries attached to the table client (cf attachQueries)
+	Queries []*PostgresQuery
+
+	// Named filters added to the table filters (cf bindNamedFilters)
+	NamedFilters []*PostgresQuery
 }
 
 type PostgresColumn struct {
@@ -28,17 +39,27 @@ type PostgresColumn struct {
 	NameJSON       string
 	Type           string
 	TypeSQL        string
+	TypeGo         string
 	Nullable       bool
 	IsArray        bool
 	HasDefault     bool
+	Length         int
+	Enum           []string
+	Comment        string
 }
 
 type PostgresQuery struct {
 	Name           string
 	NameNormalized string
+	MethodName     string
+	Comment        string
 	Method         string
 	Fields         []*PostgresColumn
 	SQLQuery       string
+	Table          string
+
+	// Table client of the query (e.g. User for db.User), empty for db.Queries
+	Client string
 
 	Select         string
 	SelectOriginal string
@@ -46,6 +67,34 @@ type PostgresQuery struct {
 	Where          string
 	GroupBy        []string
 	Having         string
+	Distinct       bool
+	DistinctOn     string
+	OrderBy        []string
+	Limit          string
+	Offset         string
+
+	Params       []*PostgresColumn
+	WhereParams  []string
+	HavingParams []string
+
+	Statement     string
+	StatementArgs []string
+	Derived       bool
+
+	Nest       []*PostgresQueryNest
+	NestFields []*PostgresColumn
+	NestKey    string
+}
+
+// Rows of a joined table grouped under their parent row (cf SQLQueryNest)
+type PostgresQueryNest struct {
+	Field     string
+	JSON      string
+	Type      string
+	Fields    []*PostgresColumn
+	Values    []string
+	Condition string
+	Key       string
 }
 
 type SelectFieldFilter struct {
@@ -70,6 +119,7 @@ type SelectFilter struct {
 	PreSQL  string
 	SQL     string
 	Args    []string
+	Fake    string
 }
 
 type SelectQueryRef struct {
@@ -94,4 +144,5 @@ type HeaderData struct {
 	Version     string
 	Deps        []string
 	Placeholder string
+	HasJSONType bool
 }
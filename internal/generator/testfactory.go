@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+type FactoryReference struct {
+	Table  string
+	Fields []FactoryReferenceField
+}
+
+type FactoryReferenceField struct {
+	Name   string
+	Parent string
+}
+
+// Foreign keys of the Create input which the test factory fills by creating the referenced row first.
+// Nullable foreign keys are left empty
+func (table *PostgresTable) GetFactoryReferences() []FactoryReference {
+	references, _ := table.factoryReferences()
+	return references
+}
+
+// References of the factory, and the fields of the references leading back to the table.
+// A self reference, or a cycle of not null foreign keys (A -> B -> A), would never end: the caller sets them (cf overrides)
+func (table *PostgresTable) factoryReferences() ([]FactoryReference, []string) {
+	references := []FactoryReference{}
+	cyclic := []string{}
+	inputs := table.GetCreateInputColumns()
+
+	for _, ref := range table.table.References {
+		parent, ok := table.schema.Tables[ref.Table]
+		if !ok || len(ref.Columns) != len(ref.TableColumns) {
+			continue
+		}
+
+		reference := FactoryReference{Table: strcase.ToCamel(plural.Singular(parent.Name))}
+		for i, name := range ref.Columns {
+			idx := slices.IndexFunc(inputs, func(col *PostgresColumn) bool { return col.Name == name })
+			parentColumn, ok := parent.Columns[ref.TableColumns[i]]
+			if idx < 0 || !ok || inputs[idx].Nullable || getColumnType(parentColumn) != inputs[idx].Type {
+				break
+			}
+			reference.Fields = append(reference.Fields, FactoryReferenceField{
+				Name:   inputs[idx].NameNormalized,
+				Parent: strcase.ToCamel(parentColumn.Name),
+			})
+		}
+
+		if len(reference.Fields) != len(ref.Columns) {
+			continue
+		}
+		if factoryReaches(table.schema, ref.Table, table.Name, map[string]bool{}) {
+			for _, field := range reference.Fields {
+				cyclic = append(cyclic, field.Name)
+			}
+			continue
+		}
+		references = append(references, reference)
+	}
+
+	return references, cyclic
+}
+
+// Whether the factory of a table creates a row of target, following the not null foreign keys of the schema
+func factoryReaches(schema *core.SQLSchema, from string, target string, visited map[string]bool) bool {
+	if from == target {
+		return true
+	}
+	table, ok := schema.Tables[from]
+	if !ok || visited[from] {
+		return false
+	}
+	visited[from] = true
+
+	for _, ref := range table.References {
+		required := len(ref.Columns) > 0
+		for _, name := range ref.Columns {
+			if col, ok := table.Columns[name]; !ok || col.Nullable {
+				required = false
+			}
+		}
+		if required && factoryReaches(schema, ref.Table, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields of the Create input filled with random values by the test factory (foreign keys excepted)
+func (table *PostgresTable) GetFactoryColumns() []*PostgresColumn {
+	references, excluded := table.factoryReferences()
+	for _, ref := range references {
+		for _, field := range ref.Fields {
+			excluded = append(excluded, field.Name)
+		}
+	}
+
+	columns := []*PostgresColumn{}
+	for _, col := range table.GetCreateInputColumns() {
+		if !slices.Contains(excluded, col.NameNormalized) && col.GetFactoryValue() != "" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// Go expression of a random value for a column (`seq` is unique per factory call), empty to keep the zero value.
+// Nullable columns are left empty
+func (col *PostgresColumn) GetFactoryValue() string {
+	if col.Nullable {
+		return ""
+	}
+
+	if len(col.Enum) > 0 && col.Type == "string" {
+		values := []string{}
+		for _, value := range col.Enum {
+			values = append(values, strconv.Quote(value))
+		}
+		return fmt.Sprintf("factoryPick(%s)", strings.Join(values, ", "))
+	}
+
+	switch col.Type {
+	case "string":
+		return fmt.Sprintf("factoryString(%q, %d, seq)", col.Name, col.Length)
+	case "[]byte":
+		return fmt.Sprintf("[]byte(factoryString(%q, %d, seq))", col.Name, col.Length)
+	case "int64":
+		return "seq"
+	case "int32", "int16", "int", "float64", "float32":
+		return fmt.Sprintf("%s(seq)", col.Type)
+	case "bool":
+		return "rand.IntN(2) == 1"
+	case "time.Time":
+		return "factoryTime()"
+	case "uuid.UUID":
+		return "uuid.New()"
+	case "interface{}":
+		return "map[string]any{}"
+	}
+
+	if strings.HasPrefix(col.Type, "[]") {
+		return col.Type + "{}"
+	}
+	return ""
+}
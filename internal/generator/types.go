@@ -2,7 +2,9 @@ package generator
 
 import (
 	"fmt"
+	"path"
 	"regexp"
+	"strings"
 
 	"github.com/kefniark/mango-sql/internal/core"
 )
@@ -32,6 +34,7 @@ var postgresType = map[string]string{
 	"bool":        "bool",
 	"json":        "interface{}",
 	"jsonb":       "interface{}",
+	"tsvector":    "string",
 }
 
 var parseType = regexp.MustCompile(`(?P<Type>[a-zA-Z]+)(?P<Accuracy>\d*)?(?P<Array>[\[\]]*)?`)
@@ -50,7 +53,9 @@ func getColumnType(column *core.SQLColumn) string {
 	}
 
 	newType := "string"
-	if val, ok := postgresType[fieldType+fieldAccuracy]; ok {
+	if column.TypeGo != "" {
+		newType, _ = parseGoType(column.TypeGo)
+	} else if val, ok := postgresType[fieldType+fieldAccuracy]; ok {
 		newType = val
 	} else if val2, ok2 := postgresType[fieldType]; ok2 {
 		newType = val2
@@ -67,3 +72,26 @@ func getColumnType(column *core.SQLColumn) string {
 	}
 	return newType
 }
+
+// Split a user declared type (`Settings`, `github.com/acme/app/models.Settings`)
+// into the type used in the generated code and the package to import
+func parseGoType(spec string) (string, string) {
+	prefix := ""
+	for strings.HasPrefix(spec, "*") || strings.HasPrefix(spec, "[]") {
+		prefix += spec[:strings.IndexAny(spec, "*]")+1]
+		spec = spec[strings.IndexAny(spec, "*]")+1:]
+	}
+
+	slash := strings.LastIndex(spec, "/")
+	if slash == -1 {
+		return prefix + spec, ""
+	}
+
+	dot := strings.Index(spec[slash:], ".")
+	if dot == -1 {
+		return prefix + spec, ""
+	}
+
+	importPath := spec[:slash+dot]
+	return prefix + path.Base(importPath) + spec[slash+dot:], importPath
+}
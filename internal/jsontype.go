@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+var regColumnTypeAnnotation = regexp.MustCompile(`(?m)^\s*([\w"\x60]+)\s[^\n]*?--\s*type:\s*(\S+)`)
+
+// Json columns can be bound to a user declared Go type with an inline comment (`settings JSONB, -- type: Settings`).
+// Comments are lost during normalization, so the annotations are extracted from the raw sql.
+func findColumnTypes(sql string) map[string]map[string]string {
+	types := map[string]map[string]string{}
+
+	for _, table := range findTableContents(sql) {
+		for _, match := range regColumnTypeAnnotation.FindAllStringSubmatch(table.Content, -1) {
+			name := normalizeName(table.Name)
+			if _, ok := types[name]; !ok {
+				types[name] = map[string]string{}
+			}
+			types[name][normalizeName(match[1])] = strings.TrimRight(match[2], ",")
+		}
+	}
+
+	return types
+}
+
+func applyColumnTypes(schema *core.SQLSchema, types map[string]map[string]string) {
+	for name, columns := range types {
+		table, ok := schema.Tables[name]
+		if !ok {
+			continue
+		}
+
+		for columnName, goType := range columns {
+			column, ok := table.Columns[columnName]
+			if !ok || !isJSONColumn(column) {
+				continue
+			}
+			column.TypeGo = goType
+		}
+	}
+}
+
+func isJSONColumn(column *core.SQLColumn) bool {
+	columnType := strings.ToLower(column.Type)
+	return columnType == "json" || columnType == "jsonb"
+}
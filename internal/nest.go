@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+// Group the rows of joined tables under their parent row (`-- nest: posts`).
+// The nested and the parent rows are identified by their primary key, which has to be selected
+func (scope *queryScope) nest(query *core.SQLQuery, m *core.QueryMacro, tables []core.TableDeps) error {
+	if len(m.Nest) == 0 {
+		return nil
+	}
+
+	nested := []string{}
+	for _, name := range m.Nest {
+		nest, err := scope.findNest(query, m, name, tables)
+		if err != nil {
+			return err
+		}
+		query.Nest = append(query.Nest, nest)
+		nested = append(nested, name)
+	}
+
+	// the parent rows are identified by the primary key of the other tables
+	for _, table := range tables {
+		alias := tableAlias(table)
+		if slices.Contains(nested, alias) || !slices.ContainsFunc(query.SelectFields, func(field *core.SQLColumn) bool { return field.TableAs == alias }) {
+			continue
+		}
+
+		keys, ok := selectedKeys(query, scope.schema, table)
+		if !ok {
+			return macroError(m, "cannot be nested, the primary key of %s is not selected", alias)
+		}
+		for _, key := range keys {
+			if key.Nullable {
+				return macroError(m, "cannot be nested, %s can be null", key.Name)
+			}
+			query.NestKeys = append(query.NestKeys, key.Name)
+		}
+	}
+
+	if len(query.NestKeys) == 0 {
+		return macroError(m, "cannot be nested, there is no parent row")
+	}
+	return nil
+}
+
+func (scope *queryScope) findNest(query *core.SQLQuery, m *core.QueryMacro, name string, tables []core.TableDeps) (*core.SQLQueryNest, error) {
+	idx := slices.IndexFunc(tables, func(table core.TableDeps) bool { return tableAlias(table) == name })
+	if idx < 0 {
+		return nil, macroError(m, "cannot nest %s, the table is not used by the query", name)
+	}
+
+	keys, ok := selectedKeys(query, scope.schema, tables[idx])
+	if !ok {
+		return nil, macroError(m, "cannot nest %s, its primary key is not selected", name)
+	}
+
+	nest := &core.SQLQueryNest{Name: name}
+	for _, key := range keys {
+		nest.Keys = append(nest.Keys, key.Name)
+	}
+
+	// without the join, the nested columns have the nullability of the schema
+	table := scope.schema.Tables[tables[idx].Names[0]]
+	for _, field := range query.SelectFields {
+		if field.TableAs != name {
+			continue
+		}
+		column := *field
+		if col, ok := table.Columns[columnName(field.Name)]; ok {
+			column.Nullable = col.Nullable
+		}
+		nest.Fields = append(nest.Fields, &column)
+	}
+
+	return nest, nil
+}
+
+// Selected fields of the primary key of a table
+func selectedKeys(query *core.SQLQuery, schema *core.SQLSchema, deps core.TableDeps) ([]*core.SQLColumn, bool) {
+	if len(deps.Names) == 0 {
+		return nil, false
+	}
+	table, ok := schema.Tables[deps.Names[0]]
+	if !ok {
+		return nil, false
+	}
+
+	keys := []*core.SQLColumn{}
+	for _, constraint := range table.Constraints {
+		if constraint.Type != "PRIMARY" {
+			continue
+		}
+		for _, name := range constraint.Columns {
+			idx := slices.IndexFunc(query.SelectFields, func(field *core.SQLColumn) bool {
+				return field.TableAs == tableAlias(deps) && columnName(field.Name) == name
+			})
+			if idx < 0 {
+				return nil, false
+			}
+			keys = append(keys, query.SelectFields[idx])
+		}
+	}
+	return keys, len(keys) > 0
+}
+
+func tableAlias(table core.TableDeps) string {
+	if table.As != "" || len(table.Names) == 0 {
+		return table.As
+	}
+	return table.Names[0]
+}
+
+func columnName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/auxten/postgresql-parser/pkg/sql/sem/tree"
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+var (
+	// Aggregates return null without rows, count excepted
+	nullableFunctions = []string{"max", "min", "sum", "avg", "any_value", "first_value", "last_value", "string_agg", "group_concat", "array_agg", "bool_and", "bool_or", "every", "nullif", "stddev", "variance"}
+	notNullFunctions  = []string{"count", "row_number", "rank", "dense_rank", "random", "concat", "concat_ws", "current_date", "gen_random_uuid", "uuid_generate_v4"}
+
+	// Comparisons which are never true for a null value
+	nullRejectingOperators = []tree.ComparisonOperator{tree.EQ, tree.LT, tree.GT, tree.LE, tree.GE, tree.NE, tree.In, tree.Like, tree.ILike}
+)
+
+// If an expression can be null: a nullable column, an aggregate, or any of the operands of an operator or a function.
+// COALESCE is null only if all its values can be null
+//
+//nolint:gocognit // One case per kind of expression
+func exprNullable(expr tree.Expr, tables []core.TableDeps, schema *core.SQLSchema) bool {
+	switch e := expr.(type) {
+	case *tree.ParenExpr:
+		return exprNullable(e.Expr, tables, schema)
+	case *tree.UnresolvedName:
+		columns := resolveTableColumns(e.String(), "", tables, schema, 0)
+		return len(columns) != 1 || columns[0].TypeSQL == "UNKNOWN" || columns[0].Nullable
+	case *tree.NumVal, *tree.StrVal, *tree.DBool:
+		return false
+	case *tree.CastExpr:
+		return exprNullable(e.Expr, tables, schema)
+	case *tree.AnnotateTypeExpr:
+		return exprNullable(e.Expr, tables, schema)
+	case *tree.UnaryExpr:
+		return exprNullable(e.Expr, tables, schema)
+	case *tree.BinaryExpr:
+		return exprNullable(e.Left, tables, schema) || exprNullable(e.Right, tables, schema)
+	case *tree.ComparisonExpr:
+		if e.Operator == tree.IsDistinctFrom || e.Operator == tree.IsNotDistinctFrom {
+			return false
+		}
+		return exprNullable(e.Left, tables, schema) || exprNullable(e.Right, tables, schema)
+	case *tree.CoalesceExpr:
+		for _, value := range e.Exprs {
+			if !exprNullable(value, tables, schema) {
+				return false
+			}
+		}
+		return true
+	case *tree.CaseExpr:
+		if e.Else == nil || exprNullable(e.Else, tables, schema) {
+			return true
+		}
+		for _, when := range e.Whens {
+			if exprNullable(when.Val, tables, schema) {
+				return true
+			}
+		}
+		return false
+	case *tree.FuncExpr:
+		name := strings.ToLower(e.Func.String())
+		switch {
+		case slices.Contains(notNullFunctions, name), slices.Contains(timestampFunctions, name):
+			return false
+		case slices.Contains(nullableFunctions, name):
+			return true
+		}
+		for _, arg := range e.Exprs {
+			if exprNullable(arg, tables, schema) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Columns which cannot be null in the result of a query, because a condition of the WHERE clause rejects null
+// (`x IS NOT NULL`, `x = ?`, `x > ?`, `x IN (...)`, ...). Only the conditions joined by AND are used
+func findNotNullColumns(where *tree.Where, tables []core.TableDeps, schema *core.SQLSchema) []string {
+	columns := []string{}
+	if where == nil {
+		return columns
+	}
+
+	var walk func(expr tree.Expr)
+	walk = func(expr tree.Expr) {
+		switch e := expr.(type) {
+		case *tree.ParenExpr:
+			walk(e.Expr)
+		case *tree.AndExpr:
+			walk(e.Left)
+			walk(e.Right)
+		case *tree.ComparisonExpr:
+			notNull := e.Operator == tree.IsDistinctFrom && e.Right == tree.DNull
+			if !notNull && !slices.Contains(nullRejectingOperators, e.Operator) {
+				return
+			}
+
+			name, ok := e.Left.(*tree.UnresolvedName)
+			if !ok {
+				return
+			}
+			for _, column := range resolveTableColumns(name.String(), "", tables, schema, 0) {
+				columns = append(columns, column.Name)
+			}
+		}
+	}
+	walk(where.Expr)
+
+	return columns
+}
@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/auxten/postgresql-parser/pkg/sql/sem/tree"
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+var (
+	regNamedParam      = regexp.MustCompile(`(?i)\bsqlc\.n?arg\(\s*'?(\w+)'?\s*\)|@(\w+)`)
+	regPositionalParam = regexp.MustCompile(`\$(\d+)`)
+)
+
+// Named parameters (`@user_id` or sqlc style `sqlc.arg(user_id)`) are not supported by the parser.
+// They are replaced by positional parameters, numbered after the ones already used in the statement ($1, $2, ...).
+// Quoted strings, identifiers and comments are left untouched.
+// The names of the positional parameters are returned per statement ("" when the parameter is not named)
+func replaceNamedParams(sql string) (string, [][]string) {
+	statements := splitStatements(sql)
+	params := make([][]string, len(statements))
+
+	for i, statement := range statements {
+		segments := splitSegments(statement)
+		names := []string{}
+		for _, segment := range segments {
+			if segment.kind != segmentCode {
+				continue
+			}
+			for _, match := range regPositionalParam.FindAllStringSubmatch(segment.text, -1) {
+				if idx, err := strconv.Atoi(match[1]); err == nil && idx > len(names) {
+					names = append(names, make([]string, idx-len(names))...)
+				}
+			}
+		}
+
+		for j, segment := range segments {
+			if segment.kind != segmentCode {
+				continue
+			}
+			segments[j].text = regNamedParam.ReplaceAllStringFunc(segment.text, func(param string) string {
+				match := regNamedParam.FindStringSubmatch(param)
+				name := strings.ToLower(match[1] + match[2])
+				idx := slices.Index(names, name)
+				if idx < 0 {
+					names = append(names, name)
+					idx = len(names) - 1
+				}
+				return fmt.Sprintf("$%d", idx+1)
+			})
+		}
+		statements[i] = joinSegments(segments)
+		params[i] = names
+	}
+
+	return strings.Join(statements, ";"), params
+}
+
+// Replace the positional parameters of a clause by `?` (the placeholder of the query builder),
+// and return the name of the parameters in order of appearance. Quoted strings and identifiers are left untouched
+func replacePositionalParams(sql string, params []*core.SQLColumn) (string, []string) {
+	names := []string{}
+	segments := splitSegments(sql)
+	for i, segment := range segments {
+		if segment.kind != segmentCode {
+			continue
+		}
+		segments[i].text = regPositionalParam.ReplaceAllStringFunc(segment.text, func(param string) string {
+			idx, err := strconv.Atoi(param[1:])
+			if err != nil || idx < 1 || idx > len(params) {
+				return param
+			}
+			names = append(names, params[idx-1].Name)
+			return "?"
+		})
+	}
+	return joinSegments(segments), names
+}
+
+// Name of the parameter used as a value (e.g. a limit), the value itself when it is not a parameter
+func paramName(value string, params []*core.SQLColumn) string {
+	match := regPositionalParam.FindStringSubmatch(value)
+	if match == nil || match[0] != value {
+		return value
+	}
+	idx, err := strconv.Atoi(match[1])
+	if err != nil || idx < 1 || idx > len(params) {
+		return value
+	}
+	return params[idx-1].Name
+}
+
+type paramVisitor struct {
+	schema  *core.SQLSchema
+	tables  []core.TableDeps
+	names   []string
+	columns map[int]*core.SQLColumn
+	count   int
+}
+
+// The type of a parameter is inferred from the column it is compared to (`=`, `<`, `LIKE`, `IN`, `BETWEEN`, `= ANY`).
+// A parameter assigned to a column (cf assignment), or compared with `IS NOT DISTINCT FROM`, can be null like the column
+func (v *paramVisitor) VisitPre(expr tree.Expr) (bool, tree.Expr) {
+	switch expr := expr.(type) {
+	case *tree.Placeholder:
+		v.count = max(v.count, int(expr.Idx)+1)
+	case *tree.ComparisonExpr:
+		array := expr.Operator == tree.Any || expr.Operator == tree.Some || expr.Operator == tree.All
+		nullable := expr.Operator == tree.IsNotDistinctFrom
+		v.bind(expr.Left, array, nullable, expr.Right)
+		v.bind(expr.Right, array, nullable, expr.Left)
+	case *tree.RangeCond:
+		v.bind(expr.Left, false, false, expr.From, expr.To)
+	}
+	return true, expr
+}
+
+func (v *paramVisitor) VisitPost(expr tree.Expr) tree.Expr {
+	return expr
+}
+
+func (v *paramVisitor) bind(column tree.Expr, array bool, nullable bool, values ...tree.Expr) {
+	name, ok := column.(*tree.UnresolvedName)
+	if !ok {
+		return
+	}
+
+	resolved := resolveTableColumns(name.String(), "", v.tables, v.schema, 0)
+	if len(resolved) != 1 || resolved[0].TypeSQL == "UNKNOWN" {
+		return
+	}
+
+	for _, value := range values {
+		// `= ANY($1)` compares to the whole array, `= ANY(ARRAY[$1, $2])` to each of its elements
+		value = tree.StripParens(value)
+		placeholders := []tree.Expr{value}
+		elements := false
+		switch value := value.(type) {
+		case *tree.Tuple:
+			placeholders = value.Exprs
+		case *tree.Array:
+			placeholders, elements = value.Exprs, true
+		}
+
+		for _, placeholder := range placeholders {
+			param, ok := placeholder.(*tree.Placeholder)
+			if !ok {
+				continue
+			}
+
+			columnType := resolved[0].Type
+			if array && !elements {
+				columnType += "[]"
+			}
+			// a parameter both assigned and compared keeps the nullability of the assignment
+			previous, ok := v.columns[int(param.Idx)]
+			v.columns[int(param.Idx)] = &core.SQLColumn{
+				Name:     name.Parts[0],
+				Type:     columnType,
+				TypeSQL:  resolved[0].TypeSQL,
+				Nullable: nullable && resolved[0].Nullable || ok && previous.Nullable,
+			}
+		}
+	}
+}
+
+func newParamVisitor(schema *core.SQLSchema, names []string) *paramVisitor {
+	return &paramVisitor{
+		schema:  schema,
+		names:   names,
+		columns: map[int]*core.SQLColumn{},
+		count:   len(names),
+	}
+}
+
+// Typed parameters of a query, named after the query annotation or the column they are compared to
+func findQueryParams(schema *core.SQLSchema, tables []core.TableDeps, names []string, exprs ...tree.Expr) []*core.SQLColumn {
+	visitor := newParamVisitor(schema, names)
+	visitor.tables = tables
+	for _, expr := range exprs {
+		if expr != nil {
+			tree.WalkExprConst(visitor, expr)
+		}
+	}
+	return visitor.params()
+}
+
+func (v *paramVisitor) params() []*core.SQLColumn {
+	params := []*core.SQLColumn{}
+	used := []string{}
+	for i := range v.count {
+		param, ok := v.columns[i]
+		if !ok {
+			param = &core.SQLColumn{Name: fmt.Sprintf("arg%d", i+1), Type: "any", TypeSQL: "UNKNOWN", TypeGo: "any"}
+		}
+		if i < len(v.names) && v.names[i] != "" {
+			param.Name = v.names[i]
+		}
+		if slices.Contains(used, param.Name) {
+			param.Name = fmt.Sprintf("%s%d", param.Name, i+1)
+		}
+		used = append(used, param.Name)
+		params = append(params, param)
+	}
+
+	return params
+}
@@ -14,47 +14,171 @@ import (
 )
 
 func ParseQueries(schema *core.SQLSchema, sql string) error {
+	sql, params := replaceNamedParams(normalizeQueries(sql, schema.Driver))
+	sql, err := wrapFilters(sql)
+	if err != nil {
+		return err
+	}
 	stmts, err := parser.Parse(sql)
 	if err != nil {
 		return fmt.Errorf("schema parsing error: %w", err)
 	}
 
-	macro := findQueriesMacro(sql)
+	macro := findQueriesMacro(sql, params)
+	parsed, parsedFilters := len(schema.Queries), len(schema.Filters)
 
+	var queryErr error
 	w := &walk.AstWalker{
 		Fn: func(_ interface{}, node interface{}) (stop bool) {
+			if queryErr != nil {
+				return true
+			}
 			switch stmt := node.(type) {
-			case *tree.SelectClause:
-				parseSelectQuery(schema, stmt, macro)
+			case *tree.Select:
+				if clause, ok := stmt.Select.(*tree.SelectClause); ok && stmt.With == nil {
+					queryErr = parseSelectQuery(schema, stmt, clause, macro)
+				} else {
+					queryErr = parseStatementQuery(schema, stmt, macro)
+				}
+				return true
+			case *tree.Insert, *tree.Update, *tree.Delete:
+				queryErr = parseWriteQuery(schema, stmt.(tree.Statement), macro)
 				return true
 			}
 			return false
 		},
 	}
 
-	_, err = w.Walk(stmts, nil)
-	return err
+	if _, err = w.Walk(stmts, nil); err != nil {
+		return err
+	}
+	if queryErr != nil {
+		return queryErr
+	}
+
+	for i := range schema.Queries[parsed:] {
+		dialectQuery(&schema.Queries[parsed+i], schema.Driver)
+	}
+	for i := range schema.Filters[parsedFilters:] {
+		dialectQuery(&schema.Filters[parsedFilters+i], schema.Driver)
+	}
+	return nil
 }
 
-var parseMacro = regexp.MustCompile(`(?m)-- (?P<method>.*):(?P<name>.*)(?P<sql>[^;]*);`)
+var (
+	parseMacro = regexp.MustCompile(`^--\s*(?P<method>\w+)\s*:(?P<name>.*)$`)
+	parseNest  = regexp.MustCompile(`(?m)^\s*--\s*nest:\s*(\w+)\s*$`)
+)
+
+var queryMethods = []string{core.QueryOne, core.QueryMany, core.Exec, core.ExecRows, core.Filter}
 
-func findQueriesMacro(sql string) []core.QueryMacro {
+// Each query is annotated with a `-- method: Name` comment, the comment lines right above it document the query
+func findQueriesMacro(sql string, params [][]string) []core.QueryMacro {
 	macro := []core.QueryMacro{}
-	for _, res := range parseMacro.FindAllStringSubmatch(sql, -1) {
-		macro = append(macro, core.QueryMacro{
-			Method: res[1],
-			Name:   res[2],
-			Query:  normalizeSQL(res[3]),
-		})
+	statements := splitStatements(sql)
+	line := 1
+	for i, statement := range statements[:len(statements)-1] {
+		lines := strings.Split(statement, "\n")
+		start := line
+		line += len(lines) - 1
+		idx := findMacroLine(lines)
+		if idx < 0 {
+			continue
+		}
+
+		match := parseMacro.FindStringSubmatch(strings.TrimSpace(lines[idx]))
+		query := strings.Join(lines[idx+1:], "\n")
+		entry := core.QueryMacro{
+			Method:  match[1],
+			Name:    match[2],
+			Comment: findDocComment(lines[:idx]),
+			Query:   normalizeSQL(formatQuery(query)),
+			Line:    start + idx,
+			Column:  strings.Index(lines[idx], "--") + 1,
+		}
+		for _, nest := range parseNest.FindAllStringSubmatch(query, -1) {
+			entry.Nest = append(entry.Nest, strings.ToLower(nest[1]))
+		}
+		if i < len(params) {
+			entry.Params = params[i]
+		}
+		macro = append(macro, entry)
 	}
 	return macro
 }
 
+// Line of the `-- method: Name` annotation, a known method is preferred to a documentation line with a colon
+func findMacroLine(lines []string) int {
+	found := -1
+	for i, line := range lines {
+		match := parseMacro.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil || strings.EqualFold(match[1], "nest") {
+			continue
+		}
+		if slices.ContainsFunc(queryMethods, func(method string) bool {
+			return strings.EqualFold(method, match[1])
+		}) {
+			return i
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	return found
+}
+
+// Comment lines right above the annotation, without the `--`
+func findDocComment(lines []string) string {
+	comment := []string{}
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		comment = append([]string{strings.TrimSpace(strings.TrimPrefix(line, "--"))}, comment...)
+	}
+	return strings.TrimSpace(strings.Join(comment, "\n"))
+}
+
+// Format a query like the parser does (e.g. parenthesis around conditions), to be compared to the parsed statements
+func formatQuery(sql string) string {
+	stmts, err := parser.Parse(sql)
+	if err != nil || len(stmts) != 1 {
+		return sql
+	}
+	return stmts[0].AST.String()
+}
+
+func normalizeQueryMethod(m *core.QueryMacro) (string, error) {
+	method := strings.TrimSpace(m.Method)
+	for _, known := range queryMethods {
+		if strings.EqualFold(method, known) {
+			return known, nil
+		}
+	}
+
+	return "", macroError(m, "unknown query method %q (queryOne, queryMany, exec, execRows or filter)", method)
+}
+
+// Error of a custom query, located at its annotation
+func macroError(m *core.QueryMacro, message string, args ...any) error {
+	return &QueryError{
+		Line:    m.Line,
+		Column:  m.Column,
+		Message: fmt.Sprintf("%s: %s", strings.TrimSpace(m.Name), fmt.Sprintf(message, args...)),
+	}
+}
+
 func normalizeSQL(sql string) string {
 	return strings.ToLower(strings.Join(strings.Fields(sql), ""))
 }
 
 func ParseSchema(sql string) (*core.SQLSchema, error) {
+	searches := findSearches(sql)
+	columnTypes := findColumnTypes(sql)
+	columnDetails := findColumnDetails(sql)
+	comments := findComments(sql)
+	views := findViews(sql)
 	sql = normalize(sql)
 	stmts, err := parser.Parse(sql)
 	if err != nil {
@@ -102,6 +226,14 @@ func ParseSchema(sql string) (*core.SQLSchema, error) {
 
 	_, err = w.Walk(stmts, nil)
 
+	applySearches(schema, searches)
+	applyColumnTypes(schema, columnTypes)
+	applyColumnDetails(schema, columnDetails)
+	applyComments(schema, comments)
+	if viewErr := applyViews(schema, views); viewErr != nil {
+		return nil, viewErr
+	}
+
 	for _, table := range schema.Tables {
 		for _, ref := range table.References {
 			refTable := schema.Tables[ref.Table]
@@ -138,136 +270,156 @@ func NamesToStrings(names tree.IndexElemList) []string {
 	return columns
 }
 
-func parseSelectQuery(schema *core.SQLSchema, stmt *tree.SelectClause, macro []core.QueryMacro) {
-	query := findTableDeps(schema, stmt, macro)
-	if query == nil {
-		return
+func parseSelectQuery(schema *core.SQLSchema, stmt *tree.Select, clause *tree.SelectClause, macro []core.QueryMacro) error {
+	query, err := findTableDeps(schema, stmt, clause, macro)
+	if query == nil || err != nil {
+		return err
 	}
 
+	if query.Method == core.Filter {
+		schema.Filters = append(schema.Filters, *query)
+		return nil
+	}
 	schema.Queries = append(schema.Queries, *query)
+	return nil
 }
 
+// Annotation of a statement, each annotation is used once (the same SQL can be used by a query and a filter)
 func findQueryMacro(query string, macro []core.QueryMacro) *core.QueryMacro {
-	for _, m := range macro {
-		if strings.HasPrefix(normalizeSQL(query), m.Query) {
+	for i, m := range macro {
+		if !m.Used && strings.HasPrefix(normalizeSQL(query), m.Query) {
+			macro[i].Used = true
 			return &m
 		}
 	}
 	return nil
 }
 
-func findTableDeps(schema *core.SQLSchema, table *tree.SelectClause, macro []core.QueryMacro) *core.SQLQuery {
+func findTableDeps(schema *core.SQLSchema, stmt *tree.Select, table *tree.SelectClause, macro []core.QueryMacro) (*core.SQLQuery, error) {
 	query := core.SQLQuery{
-		Query: table.String(),
+		Query: stmt.String(),
 	}
 
 	m := findQueryMacro(query.Query, macro)
 	if m == nil {
-		return nil
+		return nil, nil
+	}
+	method, err := normalizeQueryMethod(m)
+	if err != nil {
+		return nil, err
 	}
-	query.Method = strings.TrimSpace(m.Method)
+	query.Method = method
 	query.Name = strings.TrimSpace(m.Name)
+	query.Comment = m.Comment
 
-	var walk func(ctx *FindTableDepsCtx, nodes ...interface{})
-
-	walk = func(ctx *FindTableDepsCtx, nodes ...interface{}) {
-		for _, n := range nodes {
-			switch s := n.(type) {
-			case tree.TableExprs:
-				for _, expr := range s {
-					walk(ctx, expr)
-				}
-			case *tree.Subquery:
-			case *tree.ParenSelect:
-				walk(ctx, s.Select)
-			case *tree.Select:
-				walk(ctx, s.Select)
-			case *tree.TableName:
-				ctx.tables = append(ctx.tables, s.TableName.Normalize())
-				// fmt.Println("TableName", s.TableName)
-				query.From = s.String()
-			case *tree.AliasedTableExpr:
-				subCtx := &FindTableDepsCtx{}
-				walk(subCtx, s.Expr)
-				ctx.tables = append(ctx.tables, subCtx.tables...)
-				ctx.Tables = append(ctx.Tables, core.TableDeps{
-					Names: subCtx.tables,
-					As:    s.As.Alias.Normalize(),
-				})
-				// fmt.Println("AliasedTableExpr", s)
-			case *tree.JoinTableExpr:
-				walk(ctx, s.Left)
-				walk(ctx, s.Right)
-				query.From = s.String()
-				// fmt.Println("JoinTableExpr", s)
-			case *tree.Where:
-				if s != nil && s.Expr != nil {
-					query.Where = s.Expr.String()
-				}
-			default:
-				fmt.Printf("not supported : %T %s\n", s, s)
-			}
-		}
+	scope := newQueryScope(schema, m.Params)
+	tables := scope.resolve(&query, table)
+	if scope.err != nil {
+		return nil, macroError(m, "%v", scope.err)
+	}
+	if err = scope.nest(&query, m, tables); err != nil {
+		return nil, err
+	}
+	scope.order(&query, stmt)
+
+	query.Params = scope.params.params()
+	query.Where, query.WhereParams = replacePositionalParams(query.Where, query.Params)
+	query.Having, query.HavingParams = replacePositionalParams(query.Having, query.Params)
+	query.Limit = paramName(query.Limit, query.Params)
+	query.Offset = paramName(query.Offset, query.Params)
+	if scope.derived {
+		scope.statement(&query, table, stmt)
 	}
 
-	ctx := &FindTableDepsCtx{}
-	walk(ctx, table.From.Tables)
-	walk(ctx, table.Where)
+	return &query, nil
+}
 
-	// group by
-	for _, e := range table.GroupBy {
-		query.GroupBy = append(query.GroupBy, e.String())
+// Queries with a WITH clause (CTEs) or a set operation (UNION, INTERSECT, EXCEPT) are executed as written,
+// their columns are the ones of the first select
+func parseStatementQuery(schema *core.SQLSchema, stmt *tree.Select, macro []core.QueryMacro) error {
+	query := core.SQLQuery{
+		Query: stmt.String(),
 	}
 
-	// having
-	if table.Having != nil {
-		query.Having = table.Having.Expr.String()
+	m := findQueryMacro(query.Query, macro)
+	if m == nil {
+		return nil
 	}
+	method, err := normalizeQueryMethod(m)
+	if err != nil {
+		return err
+	}
+	query.Method = method
+	query.Name = strings.TrimSpace(m.Name)
+	query.Comment = m.Comment
 
-	names := []string{}
-	for i, st := range table.Exprs {
-		field := st.Expr.String()
-		asClean := strings.TrimSpace(strings.Trim(st.As.String(), `"'`))
+	clause := firstSelectClause(stmt.Select)
+	if clause == nil {
+		return nil
+	}
 
-		cols := resolveTableColumns(field, asClean, ctx.Tables, schema, i)
-		query.SelectFields = append(query.SelectFields, cols...)
-		names = append(names, field)
+	scope := newQueryScope(schema, m.Params)
+	scope.addWith(stmt.With)
+	tables := scope.resolve(&query, clause)
+	for _, other := range selectClauses(stmt.Select)[1:] {
+		scope.resolve(&core.SQLQuery{}, other)
+	}
+	if scope.err != nil {
+		return macroError(m, "%v", scope.err)
 	}
+	if err = scope.nest(&query, m, tables); err != nil {
+		return err
+	}
+	scope.order(&core.SQLQuery{}, stmt)
+
+	query.Params = scope.params.params()
+	scope.statement(&query, clause, stmt)
 
-	query.Select = strings.Join(names, ", ")
-	query.SelectOriginal = query.Select
-	query.SelectFields = slices.Compact(query.SelectFields)
-	slices.SortFunc(query.SelectFields, func(i, j *core.SQLColumn) int {
-		return i.Order - j.Order
-	})
+	schema.Queries = append(schema.Queries, query)
+	return nil
+}
 
+func formatSelectFields(fields []*core.SQLColumn) string {
 	selectInput := []string{}
-	for _, sel := range query.SelectFields {
+	for _, sel := range fields {
 		if sel.As != "" {
 			selectInput = append(selectInput, fmt.Sprintf("%s AS %s", sel.Name, sel.As))
 		} else {
 			selectInput = append(selectInput, sel.Name)
 		}
 	}
-	query.Select = strings.Join(selectInput, ", ")
+	return strings.Join(selectInput, ", ")
+}
 
-	return &query
+// Table and column of a (qualified) column name, any other expression is kept verbatim (e.g. `users.id + 1.5`)
+func splitColumnName(field string) (string, string) {
+	field = strings.TrimSpace(field)
+	expr, err := parser.ParseExpr(field)
+	if err != nil {
+		return "", field
+	}
+
+	switch e := expr.(type) {
+	case tree.UnqualifiedStar:
+		return "", "*"
+	case *tree.UnresolvedName:
+		name := e.Parts[0]
+		if e.Star {
+			name = "*"
+		}
+		if e.NumParts > 1 {
+			return e.Parts[1], name
+		}
+		return "", name
+	}
+	return "", field
 }
 
 //nolint:gocognit // Need refactoring
 func resolveTableColumns(field string, as string, tables []core.TableDeps, schema *core.SQLSchema, i int) []*core.SQLColumn {
-	prefix := ""
-	name := strings.TrimSpace(field)
+	prefix, name := splitColumnName(field)
 	columns := []*core.SQLColumn{}
 
-	if strings.Contains(field, ".") {
-		split := strings.Split(field, ".")
-		if !strings.Contains(split[0], "(") {
-			prefix = strings.TrimSpace(split[0])
-			name = strings.TrimSpace(split[1])
-		}
-	}
-
 	for j, table := range tables {
 		_, ok := schema.Tables[prefix]
 		if prefix != "" && prefix != table.As && !ok {
@@ -308,7 +460,9 @@ func resolveTableColumns(field string, as string, tables []core.TableDeps, schem
 						As:       strings.ToLower(strcase.ToSnake(fmt.Sprintf("%s_%s", tableName, as))),
 						Type:     column.Type,
 						TypeSQL:  column.TypeSQL,
-						Nullable: column.Nullable,
+						TypeGo:   column.TypeGo,
+						Nullable: column.Nullable || table.Nullable,
+						Comment:  column.Comment,
 						Order:    i*order3 + j*order2 + k*order1 + column.Order,
 					})
 				}
@@ -327,7 +481,9 @@ func resolveTableColumns(field string, as string, tables []core.TableDeps, schem
 					As:       strings.ToLower(strcase.ToSnake(fmt.Sprintf("%s_%s", tableName, as))),
 					Type:     field.Type,
 					TypeSQL:  field.TypeSQL,
-					Nullable: field.Nullable,
+					TypeGo:   field.TypeGo,
+					Nullable: field.Nullable || table.Nullable,
+					Comment:  field.Comment,
 					Order:    i*order3 + j*order2 + k*order1 + field.Order,
 				})
 			}
@@ -339,16 +495,13 @@ func resolveTableColumns(field string, as string, tables []core.TableDeps, schem
 
 		fieldType := "string"
 		fieldSQLType := "UNKNOWN"
+		fieldGoType := ""
 
-		for _, n := range []string{"count("} {
-			if strings.HasPrefix(strings.ToLower(name), n) {
-				fieldType = "int"
-			}
-		}
-		for _, n := range []string{"avg(", "sum(", "max(", "min("} {
-			if strings.HasPrefix(strings.ToLower(name), n) {
-				fieldType = "float"
-			}
+		inferred, nullable := inferExprType(field, tables, schema)
+		if inferred != nil {
+			fieldType = inferred.Type
+			fieldSQLType = inferred.TypeSQL
+			fieldGoType = inferred.TypeGo
 		}
 
 		if as != name {
@@ -361,7 +514,8 @@ func resolveTableColumns(field string, as string, tables []core.TableDeps, schem
 			Ref:      strcase.ToCamel(cleanupName),
 			Type:     fieldType,
 			TypeSQL:  fieldSQLType,
-			Nullable: true,
+			TypeGo:   fieldGoType,
+			Nullable: nullable,
 			Order:    i * order3,
 		})
 	}
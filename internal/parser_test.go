@@ -1,8 +1,10 @@
 package internal
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/kefniark/mango-sql/internal/core"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -239,3 +241,915 @@ func TestParseAlterColumnDrop(t *testing.T) {
 	assert.Len(t, schema.Tables["orders"].Columns, 1)
 	assert.Nil(t, schema.Tables["orders"].Columns["name"])
 }
+
+func TestParseSearchTsvector(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE posts (
+		id          UUID  PRIMARY KEY,
+		title       text  NOT NULL,
+		search      tsvector
+	);
+
+	CREATE INDEX posts_title_search ON posts USING GIN (to_tsvector('english', title));
+	`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "tsvector", schema.Tables["posts"].Columns["search"].Type)
+	assert.Equal(t, "TSVECTOR", schema.Tables["posts"].Columns["search"].TypeSQL)
+
+	assert.Len(t, schema.Tables["posts"].Searches, 2)
+	assert.Equal(t, []string{"search"}, schema.Tables["posts"].Searches[0].Columns)
+	assert.Equal(t, "to_tsvector('english', title)", schema.Tables["posts"].Searches[1].Expr)
+	assert.Equal(t, "'english'", schema.Tables["posts"].Searches[1].Config)
+}
+
+func TestParseSearchFulltext(t *testing.T) {
+	schema, err := ParseSchema("CREATE TABLE `posts` (\n" +
+		"  `id` int(11) NOT NULL AUTO_INCREMENT,\n" +
+		"  `title` varchar(255) NOT NULL,\n" +
+		"  `body` text NOT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  FULLTEXT KEY `posts_search` (`title`,`body`)\n" +
+		") ENGINE=InnoDB;\n" +
+		"CREATE FULLTEXT INDEX posts_title ON posts (title);")
+	require.NoError(t, err)
+
+	assert.Len(t, schema.Tables["posts"].Columns, 3)
+	assert.Len(t, schema.Tables["posts"].Searches, 2)
+	assert.Equal(t, "posts_search", schema.Tables["posts"].Searches[0].Name)
+	assert.Equal(t, []string{"title", "body"}, schema.Tables["posts"].Searches[0].Columns)
+	assert.Equal(t, []string{"title"}, schema.Tables["posts"].Searches[1].Columns)
+}
+
+func TestParseSearchFTS5(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		title       text NOT NULL
+	);
+
+	CREATE VIRTUAL TABLE posts_search USING fts5(title, content='posts', content_rowid='id');
+	`)
+	require.NoError(t, err)
+
+	assert.Len(t, schema.Tables, 1)
+	assert.Len(t, schema.Tables["posts"].Searches, 1)
+	assert.Equal(t, "posts_search", schema.Tables["posts"].Searches[0].Name)
+	assert.Equal(t, []string{"title"}, schema.Tables["posts"].Searches[0].Columns)
+	assert.Equal(t, "id", schema.Tables["posts"].Searches[0].RowID)
+}
+
+func TestParseJSONColumnType(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE profiles (
+		id          INTEGER PRIMARY KEY, -- type: ignored
+		settings    JSONB NOT NULL, -- type: github.com/acme/app/models.Settings
+		metadata    JSON -- type: map[string]any
+	);
+	`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "", schema.Tables["profiles"].Columns["id"].TypeGo)
+	assert.Equal(t, "github.com/acme/app/models.Settings", schema.Tables["profiles"].Columns["settings"].TypeGo)
+	assert.Equal(t, "map[string]any", schema.Tables["profiles"].Columns["metadata"].TypeGo)
+}
+
+func TestParseColumnDetails(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy');
+
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL,
+		code        CHAR(3),
+		mood        mood NOT NULL,
+		role        ENUM('admin', 'member') NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	users := schema.Tables["users"]
+	assert.Equal(t, 64, users.Columns["name"].Length)
+	assert.Equal(t, 3, users.Columns["code"].Length)
+	assert.Equal(t, 0, users.Columns["id"].Length)
+	assert.Equal(t, []string{"sad", "ok", "happy"}, users.Columns["mood"].Enum)
+	assert.Equal(t, []string{"admin", "member"}, users.Columns["role"].Enum)
+}
+
+func TestParseQueryParams(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		user_id     INTEGER NOT NULL,
+		title       VARCHAR(64) NOT NULL,
+		created_at  TIMESTAMP NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: UserPosts
+	SELECT * FROM posts
+	WHERE posts.user_id = @user_id AND title LIKE $1 AND posts.created_at BETWEEN @from AND sqlc.arg(to) AND posts.id IN (@id, @id)
+		AND posts.id = ANY(@ids) AND posts.user_id = ANY(ARRAY[@author]) AND posts.title <> '$1';
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 1)
+
+	query := schema.Queries[0]
+	names := []string{}
+	types := []string{}
+	for _, param := range query.Params {
+		names = append(names, param.Name)
+		types = append(types, param.Type)
+	}
+	assert.Equal(t, []string{"title", "user_id", "from", "to", "id", "ids", "author"}, names)
+	assert.Equal(t, []string{"string", "int", "timestamp", "timestamp", "int", "int[]", "int"}, types)
+	assert.Equal(t, []string{"user_id", "title", "from", "to", "id", "id", "ids", "author"}, query.WhereParams)
+	assert.Contains(t, query.Where, "'$1'")
+	assert.NotContains(t, strings.ReplaceAll(query.Where, "'$1'", ""), "$")
+}
+
+func TestParseQueryParamsInLiterals(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		email       VARCHAR(64) NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: GmailUsers
+	-- users with an @gmail.com address; or a mailbox set by @domain
+	SELECT * FROM users WHERE users.email LIKE '%@gmail.com' OR users.email = 'a;b' OR users.email LIKE @domain;
+
+	-- queryOne: UserById
+	SELECT * FROM users WHERE users.id = @id;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 2)
+
+	gmail := schema.Queries[0]
+	assert.Equal(t, "GmailUsers", gmail.Name)
+	assert.Equal(t, []string{"domain"}, paramNames(gmail.Params))
+	assert.Contains(t, gmail.Where, "'%@gmail.com'")
+	assert.Contains(t, gmail.Where, "'a;b'")
+
+	assert.Equal(t, []string{"id"}, paramNames(schema.Queries[1].Params))
+}
+
+func TestParseQueryMethods(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryOne: UserByName
+	SELECT * FROM users WHERE users.name = @name;
+
+	-- execrows: UserCount
+	SELECT users.id FROM users;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 2)
+
+	assert.Equal(t, core.QueryOne, schema.Queries[0].Method)
+	assert.Equal(t, core.ExecRows, schema.Queries[1].Method)
+
+	// a typo in the method is an error located at the annotation, not a different signature
+	err = ParseQueries(schema, `
+	-- queryOne: UserById
+	SELECT * FROM users WHERE users.id = @id;
+
+	-- quryMany: UserList
+	SELECT users.name FROM users;
+	`)
+	var queryErr *QueryError
+	require.ErrorAs(t, err, &queryErr)
+	assert.Equal(t, 5, queryErr.Line)
+	assert.Equal(t, 2, queryErr.Column)
+	assert.Equal(t, `5:2: UserList: unknown query method "quryMany" (queryOne, queryMany, exec, execRows or filter)`, err.Error())
+}
+
+func TestParseWriteQueries(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		user_id     INTEGER NOT NULL,
+		title       VARCHAR(64) NOT NULL,
+		created_at  TIMESTAMP NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryOne: CreatePost
+	INSERT INTO posts (user_id, title, created_at) VALUES (@user_id, $1, now()) RETURNING id, title;
+
+	-- execRows: RenamePosts
+	UPDATE posts SET title = @title WHERE posts.user_id = @user_id AND posts.id > $1;
+
+	-- exec: DeletePost
+	DELETE FROM posts WHERE posts.id = @id;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 3)
+
+	create := schema.Queries[0]
+	assert.Equal(t, core.QueryOne, create.Method)
+	assert.Equal(t, []string{"title", "user_id"}, paramNames(create.Params))
+	assert.Equal(t, []string{"user_id", "title"}, create.StatementParams)
+	assert.Len(t, create.SelectFields, 2)
+	assert.Equal(t, "INSERT INTO posts(user_id, title, created_at) VALUES (?, ?, now()) RETURNING posts.id AS posts_id, posts.title AS posts_title", create.Statement)
+
+	rename := schema.Queries[1]
+	assert.Equal(t, core.ExecRows, rename.Method)
+	assert.Equal(t, []string{"id", "title", "user_id"}, paramNames(rename.Params))
+	assert.Equal(t, []string{"title", "user_id", "id"}, rename.StatementParams)
+	assert.Equal(t, "int", rename.Params[0].Type)
+
+	remove := schema.Queries[2]
+	assert.Equal(t, core.Exec, remove.Method)
+	assert.Equal(t, "DELETE FROM posts WHERE posts.id = ?", remove.Statement)
+
+	// without RETURNING clause, there is nothing to return
+	err = ParseQueries(schema, `
+	-- queryOne: ArchivePost
+	DELETE FROM posts WHERE posts.id = @id;
+	`)
+	var queryErr *QueryError
+	require.ErrorAs(t, err, &queryErr)
+	assert.Equal(t, 2, queryErr.Line)
+	assert.Contains(t, queryErr.Message, "ArchivePost: queryOne needs a RETURNING clause")
+}
+
+func TestParseWriteQueryNullableParams(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL,
+		deleted_at  TIMESTAMP
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- exec: CreateUser
+	INSERT INTO users (id, name, deleted_at) VALUES (@id, @name, @deleted_at);
+
+	-- exec: RestoreUsers
+	UPDATE users SET deleted_at = @restored_at WHERE users.deleted_at < @deleted_before;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 2)
+
+	nullable := func(params []*core.SQLColumn) []bool {
+		values := []bool{}
+		for _, param := range params {
+			values = append(values, param.Nullable)
+		}
+		return values
+	}
+
+	create := schema.Queries[0]
+	assert.Equal(t, []string{"id", "name", "deleted_at"}, paramNames(create.Params))
+	assert.Equal(t, []bool{false, false, true}, nullable(create.Params))
+
+	// only the assigned value can be null, a compared value cannot match a null column
+	restore := schema.Queries[1]
+	assert.Equal(t, []string{"restored_at", "deleted_before"}, paramNames(restore.Params))
+	assert.Equal(t, []bool{true, false}, nullable(restore.Params))
+}
+
+func paramNames(params []*core.SQLColumn) []string {
+	names := []string{}
+	for _, param := range params {
+		names = append(names, param.Name)
+	}
+	return names
+}
+
+func TestParseSubqueries(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL
+	);
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		user_id     INTEGER NOT NULL,
+		title       VARCHAR(64) NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: UserPostCount
+	WITH counts AS (
+		SELECT posts.user_id, count(*) AS total FROM posts WHERE posts.id > @min_id GROUP BY posts.user_id
+	)
+	SELECT users.name, counts.total
+	FROM users
+	JOIN counts ON counts.user_id = users.id
+	WHERE users.name LIKE @name;
+
+	-- queryMany: LatestPosts
+	SELECT latest.title
+	FROM (SELECT posts.title, posts.user_id FROM posts WHERE posts.user_id = @user_id) AS latest;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 2)
+
+	counts := schema.Queries[0]
+	assert.Equal(t, []string{"min_id", "name"}, paramNames(counts.Params))
+	assert.Equal(t, "int", counts.Params[0].Type)
+	assert.Equal(t, "string", counts.Params[1].Type)
+	require.Len(t, counts.SelectFields, 2)
+	assert.Equal(t, "string", counts.SelectFields[0].Type)
+	assert.Equal(t, "int", counts.SelectFields[1].Type)
+	assert.Contains(t, counts.Statement, "WITH counts AS (SELECT posts.user_id")
+	assert.Contains(t, counts.Statement, "SELECT users.name AS users_name, counts.total AS counts_total_1 FROM users")
+	assert.Equal(t, []string{"min_id", "name"}, counts.StatementParams)
+
+	latest := schema.Queries[1]
+	assert.Equal(t, []string{"user_id"}, paramNames(latest.Params))
+	assert.Equal(t, "int", latest.Params[0].Type)
+	require.Len(t, latest.SelectFields, 1)
+	assert.Equal(t, "string", latest.SelectFields[0].Type)
+	assert.Contains(t, latest.Statement, "FROM (SELECT posts.title, posts.user_id FROM posts WHERE posts.user_id = ?) AS latest")
+
+	// the other tables of the schema are not changed by the query scope
+	assert.NotContains(t, schema.Tables, "counts")
+	assert.NotContains(t, schema.Tables, "latest")
+}
+
+func TestParseUnsupportedFrom(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: Series
+	SELECT g FROM generate_series(1, 10) AS g;
+	`)
+	require.EqualError(t, err, "2:2: Series: unsupported table expression ROWS FROM (generate_series(1, 10)) in FROM, only tables and subqueries can be selected")
+}
+
+func TestParseExpressionTypes(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		title       VARCHAR(64) NOT NULL,
+		score       FLOAT NOT NULL,
+		created_at  TIMESTAMP NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: PostStats
+	SELECT
+		count(*) AS total,
+		max(posts.created_at) AS latest,
+		min(posts.title) AS first_title,
+		sum(posts.score) AS score,
+		sum(posts.id) AS id_sum,
+		avg(posts.id) AS average,
+		CAST(posts.id AS TEXT) AS id_text,
+		posts.score::INT AS rounded,
+		COALESCE(max(posts.id), 0) AS max_id,
+		CASE WHEN posts.score > 1 THEN 'high' ELSE 'low' END AS level,
+		posts.id * 2 + 1 AS double_id,
+		posts.id * 1.5 AS scaled,
+		lower(posts.title) AS lower_title,
+		posts.title || '!' AS loud_title,
+		now() AS generated_at,
+		posts.created_at + interval '1 day' AS next_day,
+		posts.id > 10 AS recent,
+		unknown_function(posts.id) AS unknown
+	FROM posts
+	GROUP BY posts.id;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 1)
+
+	types := map[string]string{}
+	for _, field := range schema.Queries[0].SelectFields {
+		types[field.As] = field.Type
+	}
+	assert.Equal(t, map[string]string{
+		"total":        "int",
+		"latest":       "timestamp",
+		"first_title":  "string",
+		"score":        "float4",
+		"id_sum":       "decimal",
+		"average":      "float",
+		"id_text":      "string",
+		"rounded":      "int",
+		"max_id":       "int",
+		"level":        "string",
+		"double_id":    "int",
+		"scaled":       "float",
+		"lower_title":  "string",
+		"loud_title":   "string",
+		"generated_at": "timestamptz",
+		"next_day":     "timestamp",
+		"recent":       "bool",
+		"unknown":      "string",
+	}, types)
+}
+
+func TestParseExpressionNames(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: UserScores
+	SELECT users.name, users.id + 1.5 AS shifted, 1.5 AS ratio
+	FROM users;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 1)
+
+	names := map[string]string{}
+	for _, field := range schema.Queries[0].SelectFields {
+		names[field.As] = field.Name
+	}
+	assert.Equal(t, "users.name", names["users_name"])
+	assert.Equal(t, "users.id + 1.5", names["shifted"])
+	assert.Equal(t, "1.5", names["ratio"])
+}
+
+func TestParseNullability(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL,
+		email       VARCHAR(64)
+	);
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		user_id     INTEGER NOT NULL,
+		title       VARCHAR(64) NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: UsersWithPosts
+	SELECT users.name, users.email, posts.title, COALESCE(posts.title, 'none') AS post_title, count(posts.id) AS total, max(posts.id) AS last_id
+	FROM users
+	LEFT OUTER JOIN posts ON posts.user_id = users.id
+	GROUP BY users.name, users.email, posts.title;
+
+	-- queryMany: PostsWithUsers
+	SELECT users.name, users.email, posts.title
+	FROM users
+	RIGHT JOIN posts ON posts.user_id = users.id
+	WHERE users.email IS NOT NULL;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 2)
+
+	nullable := func(query core.SQLQuery) map[string]bool {
+		fields := map[string]bool{}
+		for _, field := range query.SelectFields {
+			fields[field.Name] = field.Nullable
+		}
+		return fields
+	}
+
+	assert.Equal(t, map[string]bool{
+		"users.name":                    false,
+		"users.email":                   true,
+		"posts.title":                   true,
+		"COALESCE(posts.title, 'none')": false,
+		"count(posts.id)":               false,
+		"max(posts.id)":                 true,
+	}, nullable(schema.Queries[0]))
+
+	// the outer side of the join is nullable, unless the condition excludes null
+	assert.Equal(t, map[string]bool{
+		"users.name":  true,
+		"users.email": false,
+		"posts.title": false,
+	}, nullable(schema.Queries[1]))
+}
+
+func TestParseNestedQuery(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL
+	);
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		user_id     INTEGER NOT NULL,
+		title       VARCHAR(64) NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: UsersWithPosts
+	-- nest: posts
+	SELECT users.id, users.name, posts.id, posts.title
+	FROM users
+	LEFT JOIN posts ON posts.user_id = users.id;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 1)
+
+	query := schema.Queries[0]
+	assert.Equal(t, []string{"users.id"}, query.NestKeys)
+	require.Len(t, query.Nest, 1)
+	assert.Equal(t, "posts", query.Nest[0].Name)
+	assert.Equal(t, []string{"posts.id"}, query.Nest[0].Keys)
+	require.Len(t, query.Nest[0].Fields, 2)
+	for _, field := range query.Nest[0].Fields {
+		assert.False(t, field.Nullable, field.Name)
+	}
+
+	// without the primary keys, the rows cannot be grouped
+	err = ParseQueries(schema, `
+	-- queryMany: UsersWithTitles
+	-- nest: posts
+	SELECT users.name, posts.title
+	FROM users
+	LEFT JOIN posts ON posts.user_id = users.id;
+	`)
+	var queryErr *QueryError
+	require.ErrorAs(t, err, &queryErr)
+	assert.Equal(t, 2, queryErr.Line)
+	assert.Equal(t, "UsersWithTitles: cannot nest posts, its primary key is not selected", queryErr.Message)
+
+	err = ParseQueries(schema, `
+	-- queryMany: UsersWithComments
+	-- nest: comments
+	SELECT users.id, posts.id FROM users LEFT JOIN posts ON posts.user_id = users.id;
+	`)
+	require.ErrorContains(t, err, "UsersWithComments: cannot nest comments, the table is not used by the query")
+}
+
+func TestParseOrderedQueries(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL
+	);
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		user_id     INTEGER NOT NULL,
+		title       VARCHAR(64) NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: LatestPosts
+	SELECT DISTINCT ON (posts.user_id) posts.user_id, posts.title
+	FROM posts
+	WHERE posts.user_id = @user_id
+	ORDER BY posts.user_id, posts.id DESC
+	LIMIT @page_size OFFSET @page;
+
+	-- queryMany: FirstUsers
+	SELECT DISTINCT users.name FROM users ORDER BY users.name LIMIT 10;
+
+	-- queryMany: Names
+	SELECT users.name FROM users
+	UNION ALL
+	SELECT posts.title FROM posts WHERE posts.id = @post_id
+	ORDER BY name
+	LIMIT @max;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 3)
+
+	latest := schema.Queries[0]
+	assert.Equal(t, "DISTINCT ON (posts.user_id)", latest.DistinctOn)
+	assert.Equal(t, []string{"posts.user_id", "posts.id DESC"}, latest.OrderBy)
+	assert.Equal(t, "page_size", latest.Limit)
+	assert.Equal(t, "page", latest.Offset)
+	assert.Equal(t, []string{"user_id", "page_size", "page"}, paramNames(latest.Params))
+	assert.Equal(t, "int", latest.Params[1].Type)
+	assert.Empty(t, latest.Statement)
+
+	first := schema.Queries[1]
+	assert.True(t, first.Distinct)
+	assert.Equal(t, []string{"users.name"}, first.OrderBy)
+	assert.Equal(t, "10", first.Limit)
+
+	// a UNION is executed as written, its rows are ordered by the output columns
+	names := schema.Queries[2]
+	assert.Equal(t, "SELECT users.name AS users_name FROM users UNION ALL SELECT posts.title FROM posts WHERE posts.id = ? ORDER BY users_name LIMIT ?", names.Statement)
+	assert.Equal(t, []string{"post_id", "max"}, names.StatementParams)
+	assert.Equal(t, "int", names.Params[0].Type)
+	assert.True(t, names.Derived)
+}
+
+func TestParseComments(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL
+	);
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		title       VARCHAR(64) NOT NULL COMMENT 'Title, it''s unique'
+	) COMMENT='Blog posts';
+
+	COMMENT ON TABLE users IS 'Registered users';
+	COMMENT ON COLUMN public.users.name IS 'Display name';
+	`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Registered users", schema.Tables["users"].Comment)
+	assert.Equal(t, "Display name", schema.Tables["users"].Columns["name"].Comment)
+	assert.Equal(t, "Blog posts", schema.Tables["posts"].Comment)
+	assert.Equal(t, "Title, it's unique", schema.Tables["posts"].Columns["title"].Comment)
+
+	err = ParseQueries(schema, `
+	-- Users with a name.
+	-- Note: the search is case sensitive
+	-- queryMany: UsersByName
+	SELECT users.name FROM users WHERE users.name = @name;
+
+	-- queryOne: UserCount
+	SELECT count(*) AS total FROM users;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 2)
+
+	query := schema.Queries[0]
+	assert.Equal(t, "UsersByName", query.Name)
+	assert.Equal(t, core.QueryMany, query.Method)
+	assert.Equal(t, "Users with a name.\nNote: the search is case sensitive", query.Comment)
+	require.Len(t, query.SelectFields, 1)
+	assert.Equal(t, "Display name", query.SelectFields[0].Comment)
+
+	assert.Empty(t, schema.Queries[1].Comment)
+}
+
+func TestValidateQueries(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL
+	);
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		user_id     INTEGER NOT NULL REFERENCES users(id),
+		title       VARCHAR(64) NOT NULL
+	);
+	`)
+	require.NoError(t, err)
+
+	files := []core.QueryFile{
+		{Path: "users.sql", SQL: `-- queryMany: UserNames
+SELECT id, nam FROM users;
+
+-- queryMany: UserPosts
+SELECT id, title FROM users JOIN posts ON posts.user_id = users.id;
+
+SELECT * FROM users;
+
+-- queryOne: UserPostCount
+SELECT u.id FROM users u WHERE u.foo = 1 AND u.id IN (SELECT user_id FROM comments);`},
+		{Path: "posts.sql", SQL: `-- queryMany: UserNames
+SELECT name FROM users;
+
+-- queryMany: PostTitles
+SELECT p.title FROM posts p WHERE EXISTS (SELECT 1 FROM users WHERE users.id = p.user_id AND name = @name);`},
+		{Path: "series.sql", SQL: `-- queryMany: SeriesUsers
+SELECT users.name FROM users WHERE users.id IN (SELECT g FROM generate_series(1, 10) AS g);`},
+	}
+	for _, file := range files {
+		require.NoError(t, ParseQueries(schema, file.SQL))
+	}
+
+	// not parsed, an unknown method is a parsing error
+	files = append(files, core.QueryFile{Path: "typos.sql", SQL: `-- quryOne: UserTypo
+SELECT users.name FROM users;`})
+
+	messages := []string{}
+	for _, err := range ValidateQueries(schema, files) {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"users.sql:2:12: unknown column nam",
+		"users.sql:5:8: ambiguous column id (in users, posts)",
+		"users.sql:7:1: query without annotation (e.g. `-- queryMany: Name`)",
+		"users.sql:10:75: unknown table comments",
+		"users.sql:10:32: unknown column u.foo",
+		"posts.sql:1:1: duplicate query name UserNames (first declared at users.sql:1)",
+		"series.sql:2:63: unsupported table expression ROWS FROM (generate_series(1, 10)) in FROM, only tables and subqueries can be selected",
+		`typos.sql:1:1: unknown query method "quryOne"`,
+	}, messages)
+}
+
+func TestParseDialectQueries(t *testing.T) {
+	sql := `
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL,
+		` + "`order`" + `     INTEGER
+	);
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		user_id     INTEGER NOT NULL REFERENCES users(id),
+		title       VARCHAR(64) NOT NULL
+	);
+	`
+
+	schema, err := ParseSchema(sql)
+	require.NoError(t, err)
+	schema.Driver = core.DriverMysql
+
+	err = ParseQueries(schema, `
+	-- queryMany: UserTitles
+	SELECT `+"`users`.`order`"+`, IFNULL(users.name, "anonymous") AS name, GROUP_CONCAT(posts.title ORDER BY posts.id DESC SEPARATOR ', ') AS titles
+	FROM users
+	JOIN posts ON posts.user_id = users.id
+	WHERE users.name REGEXP '^a' AND CAST(users.id AS UNSIGNED) > 0
+	GROUP BY users.id, `+"`order`"+`
+	LIMIT 10, 20;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 1)
+
+	query := schema.Queries[0]
+	assert.Equal(t, "users.order, IFNULL(users.name, 'anonymous'), group_concat(posts.title ORDER BY posts.id DESC SEPARATOR ', ')", query.SelectOriginal)
+	assert.Equal(t, "(users.name REGEXP '^a') AND (CAST(users.id AS SIGNED) > 0)", query.Where)
+	assert.Equal(t, []string{"users.id", "`order`"}, query.GroupBy)
+	assert.Equal(t, "20", query.Limit)
+	assert.Equal(t, "10", query.Offset)
+	require.Len(t, query.SelectFields, 3)
+	assert.False(t, query.SelectFields[1].Nullable)
+	assert.Equal(t, "string", query.SelectFields[2].Type)
+
+	schema, err = ParseSchema(sql)
+	require.NoError(t, err)
+	schema.Driver = core.DriverSqlite
+
+	err = ParseQueries(schema, `
+	-- queryMany: UserTitles
+	SELECT users.name, group_concat(posts.title, ', ') AS titles
+	FROM users
+	JOIN posts ON posts.user_id = users.id
+	WHERE users.name GLOB 'a*' AND CAST(users.id AS TEXT) <> ''
+	GROUP BY users.id
+	LIMIT @offset, @limit;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 1)
+
+	query = schema.Queries[0]
+	assert.Equal(t, "users.name, group_concat(posts.title, ', ')", query.SelectOriginal)
+	assert.Equal(t, "(users.name GLOB 'a*') AND (CAST(users.id AS TEXT) != '')", query.Where)
+	assert.Equal(t, "limit", query.Limit)
+	assert.Equal(t, "offset", query.Offset)
+}
+
+func TestParseNamedFilters(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL,
+		deleted_at  TIMESTAMP
+	);
+	`)
+	require.NoError(t, err)
+
+	err = ParseQueries(schema, `
+	-- queryMany: UserNotDeleted
+	SELECT * FROM users WHERE users.deleted_at IS NULL;
+
+	-- Users which are not soft deleted
+	-- filter: Active
+	users.deleted_at IS NULL;
+
+	-- filter: NamedLike
+	users.name LIKE @pattern OR users.id = @id;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Queries, 1)
+	require.Len(t, schema.Filters, 2)
+
+	filter := schema.Filters[0]
+	assert.Equal(t, "Active", filter.Name)
+	assert.Equal(t, core.Filter, filter.Method)
+	assert.Equal(t, "users", filter.Table)
+	assert.Equal(t, "users.deleted_at IS NULL", filter.Where)
+	assert.Equal(t, "Users which are not soft deleted", filter.Comment)
+
+	filter = schema.Filters[1]
+	assert.Equal(t, "(users.name LIKE ?) OR (users.id = ?)", filter.Where)
+	assert.Equal(t, []string{"pattern", "id"}, filter.WhereParams)
+	require.Len(t, filter.Params, 2)
+	assert.Equal(t, "string", filter.Params[0].Type)
+	assert.Equal(t, "int", filter.Params[1].Type)
+
+	err = ParseQueries(schema, `
+	-- filter: Unqualified
+	deleted_at IS NULL;
+	`)
+	require.ErrorContains(t, err, "filter Unqualified: its columns have to be qualified")
+}
+
+func TestParseViews(t *testing.T) {
+	schema, err := ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY,
+		name        VARCHAR(64) NOT NULL,
+		deleted_at  TIMESTAMP
+	);
+	CREATE TABLE posts (
+		id          INTEGER PRIMARY KEY,
+		user_id     INTEGER NOT NULL REFERENCES users(id),
+		title       VARCHAR(64)
+	);
+
+	CREATE VIEW active_users AS
+		SELECT users.id, users.name FROM users WHERE users.deleted_at IS NULL;
+
+	CREATE OR REPLACE VIEW post_titles (post_id, author, title) AS
+		SELECT posts.id, active_users.name, posts.title
+		FROM posts
+		JOIN active_users ON active_users.id = posts.user_id
+		WHERE posts.title IS NOT NULL;
+
+	CREATE MATERIALIZED VIEW user_stats AS
+		SELECT users.id AS user_id, count(posts.id) AS total
+		FROM users
+		LEFT JOIN posts ON posts.user_id = users.id
+		GROUP BY users.id
+	WITH NO DATA;
+
+	CREATE VIEW titled_posts AS
+		SELECT posts.id, posts.title FROM posts WHERE posts.title <> 'draft; old';
+
+	CREATE VIEW dropped AS SELECT users.id FROM users;
+	DROP VIEW IF EXISTS dropped;
+	`)
+	require.NoError(t, err)
+	require.Len(t, schema.Tables, 6)
+	assert.NotContains(t, schema.Tables, "dropped")
+
+	view := schema.Tables["active_users"]
+	require.NotNil(t, view)
+	assert.True(t, view.View)
+	assert.False(t, view.Materialized)
+	require.Len(t, view.Columns, 2)
+	assert.Equal(t, "int", view.Columns["id"].Type)
+	assert.Equal(t, "string", view.Columns["name"].Type)
+	assert.False(t, view.Columns["name"].Nullable)
+
+	view = schema.Tables["post_titles"]
+	require.NotNil(t, view)
+	require.Len(t, view.Columns, 3)
+	assert.Equal(t, "int", view.Columns["post_id"].Type)
+	assert.Equal(t, "string", view.Columns["author"].Type)
+	assert.False(t, view.Columns["title"].Nullable)
+
+	view = schema.Tables["user_stats"]
+	require.NotNil(t, view)
+	assert.True(t, view.Materialized)
+	require.Len(t, view.Columns, 2)
+	assert.Equal(t, "int", view.Columns["total"].Type)
+	assert.Greater(t, view.Order, schema.Tables["posts"].Order)
+
+	// the query of a view ends at the first ; outside of a literal
+	view = schema.Tables["titled_posts"]
+	require.NotNil(t, view)
+	require.Len(t, view.Columns, 2)
+
+	_, err = ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY
+	);
+	CREATE VIEW missing AS SELECT accounts.id FROM accounts;
+	`)
+	require.EqualError(t, err, "view missing: unknown table accounts")
+
+	_, err = ParseSchema(`
+	CREATE TABLE users (
+		id          INTEGER PRIMARY KEY
+	);
+	CREATE VIEW constants AS VALUES (1);
+	`)
+	require.EqualError(t, err, "view constants: its columns cannot be resolved")
+}
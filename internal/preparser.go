@@ -12,12 +12,15 @@ import (
 var alter = []func(string) string{
 	removeComments,
 	removeTrigger,
+	replacePostgresEnumTypes,
 	filterValidOperations,
+	replaceMysqlFulltext,
 	replaceMysqlTypes,
 	replaceMysqlChartset,
 	replacePostgresIndexWhere,
 	replacePostgresInherits,
 	replacePostgresTypes,
+	replacePostgresTsvector,
 	replacePostgresBinarySubType,
 	replaceMysqlBacktips,
 	replaceMysqlKey,
@@ -177,8 +180,17 @@ func findFields(table string, offset int) []TableField {
 				line = table[from:pos]
 			}
 
+			// keep trailing comments out of the field, or the type rewrite would comment out what follows
+			if i := strings.Index(line, "--"); i > -1 && strings.Count(line[:i], "'")%2 == 0 {
+				line = line[:i]
+			}
+			if strings.TrimSpace(line) == "" {
+				from = pos + 1
+				continue
+			}
+
 			clean := strings.ToLower(strings.TrimSpace(line))
-			if strings.HasPrefix(clean, "primary") || strings.HasPrefix(clean, "constraint") || strings.HasPrefix(clean, "unique") || strings.HasPrefix(clean, "foreign") || strings.HasPrefix(clean, "key") {
+			if strings.HasPrefix(clean, "primary") || strings.HasPrefix(clean, "constraint") || strings.HasPrefix(clean, "unique") || strings.HasPrefix(clean, "foreign") || strings.HasPrefix(clean, "key") || strings.HasPrefix(clean, "fulltext") {
 				from = pos + 1
 				continue
 			}
@@ -332,7 +344,7 @@ func replaceMysqlTextTypes(sql string) string {
 	return strings.ReplaceAll(sql, "text text", "text")
 }
 
-var regLineTypeBinary = regexp.MustCompile(`(?i)(binary|longblob|mediumblob|tinyblob|blob|tsvector)(\(\d*\))?`)
+var regLineTypeBinary = regexp.MustCompile(`(?i)(binary|longblob|mediumblob|tinyblob|blob)(\(\d*\))?`)
 
 func replaceMysqlDataTypes(sql string) string {
 	matches := regLineTypeBinary.FindAllStringSubmatchIndex(sql, -1)
@@ -376,7 +388,7 @@ func replaceMysqlChartset(sql string) string {
 		sql = sql[:match[2]] + " " + sql[match[3]:]
 	}
 
-	regComment := regexp.MustCompile(`(?i)\sCOMMENT\s'.*'`)
+	regComment := regexp.MustCompile(`(?i)\sCOMMENT\s*=?\s*'.*'`)
 	matchesComment := regComment.FindAllStringSubmatchIndex(sql, -1)
 	slices.Reverse(matchesComment)
 	for _, match := range matchesComment {
@@ -434,6 +446,55 @@ func replacePostgresTypes(sql string) string {
 	return sql
 }
 
+// Postgres enum types are not supported by cockroachDB, the columns are declared as text (cf findColumnDetails)
+func replacePostgresEnumTypes(sql string) string {
+	enums := map[string]bool{}
+	for _, match := range regEnumType.FindAllStringSubmatch(sql, -1) {
+		enums[normalizeName(match[1])] = true
+	}
+	if len(enums) == 0 {
+		return sql
+	}
+
+	tables := findTableContents(sql)
+	slices.Reverse(tables)
+	for _, table := range tables {
+		fields := table.Fields
+		slices.Reverse(fields)
+		for _, field := range fields {
+			typeName := firstWord(field.Type)
+			if !enums[normalizeName(typeName)] {
+				continue
+			}
+			start := field.VarEnd + strings.Index(sql[field.VarEnd:], typeName)
+			sql = sql[:start] + "text" + sql[start+len(typeName):]
+		}
+	}
+
+	return sql
+}
+
+func firstWord(sql string) string {
+	if words := strings.Fields(sql); len(words) > 0 {
+		return words[0]
+	}
+	return ""
+}
+
+var regTsvectorType = regexp.MustCompile(`(?i)\btsvector\b`)
+
+// Postgres tsvector is not supported by cockroachDB, the type is restored after parsing (cf findSearches)
+func replacePostgresTsvector(sql string) string {
+	return regTsvectorType.ReplaceAllString(sql, "text")
+}
+
+var regMysqlFulltext = regexp.MustCompile(`(?i),?\s*FULLTEXT\s+(KEY|INDEX)?[^,()]*\([^)]*\)`)
+
+// Mysql FULLTEXT indexes are not supported by cockroachDB, they are extracted before (cf findSearches)
+func replaceMysqlFulltext(sql string) string {
+	return regMysqlFulltext.ReplaceAllString(sql, "")
+}
+
 type Match struct {
 	start int
 	end   int
@@ -471,6 +532,10 @@ func filterValidOperations(sql string) string {
 				continue
 			}
 
+			if strings.HasPrefix(strings.ToLower(txt), "alter table") && strings.Contains(strings.ToLower(txt), " add fulltext") {
+				continue
+			}
+
 			matches = append(matches, Match{
 				start: res[0],
 				end:   res[1],
@@ -65,3 +65,15 @@ func TestBlob(t *testing.T) {
 
 	assert.Len(t, schema.Tables["actor"].Columns, 5)
 }
+
+func TestTrailingComments(t *testing.T) {
+	schema, err := ParseSchema(`
+		CREATE TABLE actor (
+			actor_id numeric NOT NULL, -- primary key
+			first_name VARCHAR(45) NOT NULL -- not unique
+		);
+	`)
+	require.NoError(t, err)
+
+	assert.Len(t, schema.Tables["actor"].Columns, 2)
+}
@@ -0,0 +1,327 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/auxten/postgresql-parser/pkg/sql/parser"
+	"github.com/auxten/postgresql-parser/pkg/sql/sem/tree"
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+// Tables visible by a custom query: the schema tables, and the CTEs or derived tables (`FROM (SELECT ...) AS t`)
+// registered as tables with the columns of their select list.
+// A query using them cannot be rebuilt by the query builder, it is executed as written
+type queryScope struct {
+	schema  *core.SQLSchema
+	params  *paramVisitor
+	derived bool
+
+	// First FROM expression which cannot be resolved (e.g. a table function)
+	err error
+}
+
+func newQueryScope(schema *core.SQLSchema, params []string) *queryScope {
+	scope := &core.SQLSchema{
+		Tables:  maps.Clone(schema.Tables),
+		Queries: schema.Queries,
+	}
+	return &queryScope{
+		schema: scope,
+		params: newParamVisitor(scope, params),
+	}
+}
+
+func (scope *queryScope) addWith(with *tree.With) {
+	if with == nil {
+		return
+	}
+
+	scope.derived = true
+	for _, cte := range with.CTEList {
+		if stmt, ok := cte.Stmt.(*tree.Select); ok {
+			scope.addTable(cte.Name.Alias.Normalize(), NameListToStrings(cte.Name.Cols), stmt)
+		}
+	}
+}
+
+// Register the output columns of a select as a table of the scope.
+// They are named after their alias, their column or the column list of the CTE (`WITH t(a, b) AS ...`)
+func (scope *queryScope) addTable(name string, names []string, stmt *tree.Select) {
+	scope.derived = true
+	scope.addWith(stmt.With)
+
+	clause := firstSelectClause(stmt.Select)
+	if clause == nil {
+		return
+	}
+
+	tables := scope.resolve(&core.SQLQuery{}, clause)
+	notNull := findNotNullColumns(clause.Where, tables, scope.schema)
+	table := &core.SQLTable{
+		Name:    name,
+		Columns: map[string]*core.SQLColumn{},
+	}
+
+	for i, st := range clause.Exprs {
+		field := st.Expr.String()
+		as := strings.TrimSpace(strings.Trim(st.As.String(), `"'`))
+
+		for _, col := range resolveTableColumns(field, as, tables, scope.schema, i) {
+			output := as
+			if output == "" {
+				output = col.Name[strings.LastIndex(col.Name, ".")+1:]
+			}
+			if idx := len(table.Columns); idx < len(names) {
+				output = names[idx]
+			}
+
+			table.Columns[output] = &core.SQLColumn{
+				Name:     output,
+				Type:     col.Type,
+				TypeSQL:  col.TypeSQL,
+				TypeGo:   col.TypeGo,
+				Nullable: col.Nullable && !slices.Contains(notNull, col.Name),
+				Table:    name,
+				Order:    len(table.Columns),
+			}
+		}
+	}
+
+	scope.schema.Tables[name] = table
+}
+
+// Resolve the tables, conditions and fields of a select clause (cf SQLQuery), and type its parameters
+func (scope *queryScope) resolve(query *core.SQLQuery, clause *tree.SelectClause) []core.TableDeps {
+	var walk func(ctx *FindTableDepsCtx, nodes ...interface{})
+
+	walk = func(ctx *FindTableDepsCtx, nodes ...interface{}) {
+		for _, n := range nodes {
+			switch s := n.(type) {
+			case tree.TableExprs:
+				for _, expr := range s {
+					walk(ctx, expr)
+				}
+			case *tree.Subquery:
+			case *tree.ParenSelect:
+				walk(ctx, s.Select)
+			case *tree.Select:
+				walk(ctx, s.Select)
+			case *tree.TableName:
+				ctx.tables = append(ctx.tables, s.TableName.Normalize())
+				if query.Table == "" {
+					query.Table = s.TableName.Normalize()
+				}
+				query.From = s.String()
+			case *tree.AliasedTableExpr:
+				if sub, ok := s.Expr.(*tree.Subquery); ok {
+					if stmt, ok := sub.Select.(*tree.ParenSelect); ok {
+						scope.addTable(s.As.Alias.Normalize(), NameListToStrings(s.As.Cols), stmt.Select)
+					}
+					ctx.tables = append(ctx.tables, s.As.Alias.Normalize())
+					ctx.Tables = append(ctx.Tables, core.TableDeps{
+						Names: []string{s.As.Alias.Normalize()},
+					})
+					continue
+				}
+
+				subCtx := &FindTableDepsCtx{}
+				walk(subCtx, s.Expr)
+				ctx.tables = append(ctx.tables, subCtx.tables...)
+				ctx.Tables = append(ctx.Tables, core.TableDeps{
+					Names: subCtx.tables,
+					As:    s.As.Alias.Normalize(),
+				})
+			case *tree.JoinTableExpr:
+				start := len(ctx.Tables)
+				walk(ctx, s.Left)
+				middle := len(ctx.Tables)
+				walk(ctx, s.Right)
+				query.From = s.String()
+
+				// the columns of the outer side of the join can be null
+				switch s.JoinType {
+				case tree.AstLeft:
+					markNullable(ctx.Tables[middle:])
+				case tree.AstRight:
+					markNullable(ctx.Tables[start:middle])
+				case tree.AstFull:
+					markNullable(ctx.Tables[start:])
+				}
+			case *tree.Where:
+				if s != nil && s.Expr != nil {
+					query.Where = s.Expr.String()
+				}
+			default:
+				if node, ok := s.(tree.NodeFormatter); ok && scope.err == nil {
+					scope.err = fmt.Errorf("unsupported table expression %s in FROM, only tables and subqueries can be selected", tree.AsString(node))
+				}
+			}
+		}
+	}
+
+	ctx := &FindTableDepsCtx{}
+	walk(ctx, clause.From.Tables)
+	walk(ctx, clause.Where)
+
+	// parameters
+	scope.params.tables = ctx.Tables
+	if clause.Where != nil {
+		tree.WalkExprConst(scope.params, clause.Where.Expr)
+	}
+	if clause.Having != nil {
+		tree.WalkExprConst(scope.params, clause.Having.Expr)
+	}
+
+	// group by
+	for _, e := range clause.GroupBy {
+		query.GroupBy = append(query.GroupBy, e.String())
+	}
+
+	// having
+	if clause.Having != nil {
+		query.Having = clause.Having.Expr.String()
+	}
+
+	// distinct
+	if len(clause.DistinctOn) > 0 {
+		query.DistinctOn = tree.AsString(&clause.DistinctOn)
+	} else {
+		query.Distinct = clause.Distinct
+	}
+
+	names := []string{}
+	for i, st := range clause.Exprs {
+		field := st.Expr.String()
+		asClean := strings.TrimSpace(strings.Trim(st.As.String(), `"'`))
+
+		cols := resolveTableColumns(field, asClean, ctx.Tables, scope.schema, i)
+		query.SelectFields = append(query.SelectFields, cols...)
+		names = append(names, field)
+	}
+
+	notNull := findNotNullColumns(clause.Where, ctx.Tables, scope.schema)
+	for _, field := range query.SelectFields {
+		if slices.Contains(notNull, field.Name) {
+			field.Nullable = false
+		}
+	}
+
+	query.Select = strings.Join(names, ", ")
+	query.SelectOriginal = query.Select
+	query.SelectFields = slices.Compact(query.SelectFields)
+	slices.SortFunc(query.SelectFields, func(i, j *core.SQLColumn) int {
+		return i.Order - j.Order
+	})
+	query.Select = formatSelectFields(query.SelectFields)
+
+	return ctx.Tables
+}
+
+// ORDER BY, LIMIT and OFFSET of a select (cf SQLQuery).
+// A limit which is neither a number nor a parameter (e.g. `LIMIT ALL`) cannot be set by the query builder,
+// the query is executed as written
+func (scope *queryScope) order(query *core.SQLQuery, stmt *tree.Select) {
+	for _, order := range stmt.OrderBy {
+		tree.WalkExprConst(scope.params, order.Expr)
+		query.OrderBy = append(query.OrderBy, tree.AsString(order))
+	}
+
+	if stmt.Limit == nil {
+		return
+	}
+	query.Limit = scope.limit(stmt.Limit.Count, "limit")
+	query.Offset = scope.limit(stmt.Limit.Offset, "offset")
+	if stmt.Limit.LimitAll {
+		scope.derived = true
+	}
+}
+
+// Value of a LIMIT or OFFSET, its parameter is an integer
+func (scope *queryScope) limit(expr tree.Expr, name string) string {
+	switch e := expr.(type) {
+	case nil:
+		return ""
+	case *tree.NumVal:
+		return e.String()
+	case *tree.Placeholder:
+		scope.params.count = max(scope.params.count, int(e.Idx)+1)
+		scope.params.columns[int(e.Idx)] = &core.SQLColumn{Name: name, Type: "int", TypeSQL: "INT8"}
+		return e.String()
+	}
+
+	tree.WalkExprConst(scope.params, expr)
+	scope.derived = true
+	return ""
+}
+
+// The query is executed as written, with the select list of its model (e.g. `users.id AS users_id`)
+func (scope *queryScope) statement(query *core.SQLQuery, clause *tree.SelectClause, stmt tree.NodeFormatter) {
+	if stmts, err := parser.Parse("SELECT " + query.Select); err == nil && len(stmts) == 1 {
+		if sel, ok := stmts[0].AST.(*tree.Select); ok {
+			if selectClause, ok := sel.Select.(*tree.SelectClause); ok {
+				clause.Exprs = selectClause.Exprs
+			}
+		}
+	}
+
+	// the ORDER BY of a UNION refers to the output columns, which are renamed
+	if sel, ok := stmt.(*tree.Select); ok {
+		if _, ok := sel.Select.(*tree.UnionClause); ok {
+			for _, order := range sel.OrderBy {
+				order.Expr = outputColumn(order.Expr, query.SelectFields)
+			}
+		}
+	}
+
+	query.Statement, query.StatementParams = replacePositionalParams(tree.AsString(stmt), query.Params)
+	query.Derived = true
+}
+
+func markNullable(tables []core.TableDeps) {
+	for i := range tables {
+		tables[i].Nullable = true
+	}
+}
+
+// Output column of a select field (its alias), used to order the rows of a UNION
+func outputColumn(expr tree.Expr, fields []*core.SQLColumn) tree.Expr {
+	name, ok := expr.(*tree.UnresolvedName)
+	if !ok {
+		return expr
+	}
+
+	for _, field := range fields {
+		if field.As == "" {
+			continue
+		}
+		if field.Name == name.String() || strings.HasSuffix(field.Name, "."+name.String()) {
+			alias := tree.MakeUnresolvedName(field.As)
+			return &alias
+		}
+	}
+	return expr
+}
+
+// Select clause defining the columns of a select (the first one of a UNION)
+func firstSelectClause(stmt tree.SelectStatement) *tree.SelectClause {
+	if clauses := selectClauses(stmt); len(clauses) > 0 {
+		return clauses[0]
+	}
+	return nil
+}
+
+// Select clauses of a select, in order (the operands of a UNION, INTERSECT or EXCEPT)
+func selectClauses(stmt tree.SelectStatement) []*tree.SelectClause {
+	switch s := stmt.(type) {
+	case *tree.SelectClause:
+		return []*tree.SelectClause{s}
+	case *tree.ParenSelect:
+		return selectClauses(s.Select.Select)
+	case *tree.UnionClause:
+		return append(selectClauses(s.Left.Select), selectClauses(s.Right.Select)...)
+	}
+	return nil
+}
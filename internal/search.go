@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+// Full-text search definitions are not supported by the cockroachDB parser (tsvector, to_tsvector, FULLTEXT, fts5),
+// so they are extracted from the raw sql before normalization and attached to the tables after parsing.
+func findSearches(sql string) map[string][]*core.SQLTableSearch {
+	searches := map[string][]*core.SQLTableSearch{}
+
+	for _, table := range findTableContents(sql) {
+		name := normalizeName(table.Name)
+		for _, field := range table.Fields {
+			if !regTsvector.MatchString(field.Type) {
+				continue
+			}
+			searches[name] = append(searches[name], &core.SQLTableSearch{
+				Name:    normalizeName(field.Name),
+				Columns: []string{normalizeName(field.Name)},
+				Type:    core.SearchTsvector,
+			})
+		}
+
+		for _, match := range regInlineFulltext.FindAllStringSubmatch(table.Content, -1) {
+			searches[name] = append(searches[name], &core.SQLTableSearch{
+				Name:    normalizeName(match[1]),
+				Columns: splitNames(match[2]),
+				Type:    core.SearchFulltext,
+			})
+		}
+	}
+
+	for _, match := range regFulltextIndex.FindAllStringSubmatch(sql, -1) {
+		name := normalizeName(match[2])
+		searches[name] = append(searches[name], &core.SQLTableSearch{
+			Name:    normalizeName(match[1]),
+			Columns: splitNames(match[3]),
+			Type:    core.SearchFulltext,
+		})
+	}
+
+	for _, match := range regAlterFulltext.FindAllStringSubmatch(sql, -1) {
+		name := normalizeName(match[1])
+		searches[name] = append(searches[name], &core.SQLTableSearch{
+			Name:    normalizeName(match[2]),
+			Columns: splitNames(match[3]),
+			Type:    core.SearchFulltext,
+		})
+	}
+
+	for _, match := range regTsvectorIndex.FindAllStringSubmatch(sql, -1) {
+		name := normalizeName(match[2])
+		expr := strings.Join(strings.Fields(match[3]), " ")
+		search := &core.SQLTableSearch{
+			Name: normalizeName(match[1]),
+			Type: core.SearchTsvector,
+			Expr: expr,
+		}
+		if config := regTsvectorConfig.FindStringSubmatch(expr); config != nil {
+			search.Config = config[1]
+		}
+		searches[name] = append(searches[name], search)
+	}
+
+	for _, match := range regFts5.FindAllStringSubmatch(sql, -1) {
+		search := &core.SQLTableSearch{
+			Name:  normalizeName(match[1]),
+			Type:  core.SearchFTS5,
+			RowID: "rowid",
+		}
+
+		content := ""
+		for _, arg := range strings.Split(match[2], ",") {
+			key, value, isOption := strings.Cut(arg, "=")
+			if !isOption {
+				if fields := strings.Fields(arg); len(fields) > 0 {
+					search.Columns = append(search.Columns, normalizeName(fields[0]))
+				}
+				continue
+			}
+
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "content":
+				content = normalizeName(value)
+			case "content_rowid":
+				search.RowID = normalizeName(value)
+			}
+		}
+
+		// standalone fts5 tables are not linked to any table
+		if content == "" {
+			continue
+		}
+		searches[content] = append(searches[content], search)
+	}
+
+	return searches
+}
+
+var (
+	regTsvector       = regexp.MustCompile(`(?i)^\s*tsvector\b`)
+	regTsvectorIndex  = regexp.MustCompile(`(?i)CREATE\s+INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\S+)\s+ON\s+(?:ONLY\s+)?(\S+)\s+USING\s+gin\s*\(\s*(to_tsvector[^;]*?)\)\s*(?:WHERE[^;]*)?;`)
+	regTsvectorConfig = regexp.MustCompile(`(?i)^to_tsvector\(\s*('[^']*'(?:::regconfig)?)\s*,`)
+	regInlineFulltext = regexp.MustCompile(`(?i)FULLTEXT\s+(?:KEY|INDEX)?\s*([\x60"\w]*)\s*\(([^)]*)\)`)
+	regFulltextIndex  = regexp.MustCompile(`(?i)CREATE\s+FULLTEXT\s+INDEX\s+(\S+)\s+ON\s+(\S+)\s*\(([^)]*)\)`)
+	regAlterFulltext  = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+(\S+)\s+ADD\s+FULLTEXT\s+(?:KEY|INDEX)?\s*([\x60"\w]*)\s*\(([^)]*)\)`)
+	regFts5           = regexp.MustCompile(`(?i)CREATE\s+VIRTUAL\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\S+)\s+USING\s+fts5\s*\(([^;]*)\)\s*;`)
+)
+
+func applySearches(schema *core.SQLSchema, searches map[string][]*core.SQLTableSearch) {
+	for name, entries := range searches {
+		table, ok := schema.Tables[name]
+		if !ok {
+			continue
+		}
+
+		for _, search := range entries {
+			if search.Type == core.SearchTsvector && search.Expr == "" {
+				column, ok := table.Columns[search.Columns[0]]
+				if !ok {
+					continue
+				}
+				column.Type = "tsvector"
+				column.TypeSQL = "TSVECTOR"
+			}
+			table.Searches = append(table.Searches, search)
+		}
+	}
+}
+
+// Normalize a table or column name the same way the parser does (without quotes and schema)
+func normalizeName(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndex(name, "."); i > -1 {
+		name = name[i+1:]
+	}
+
+	quoted := strings.ContainsAny(name, "\"`'")
+	name = strings.Trim(name, "\"`'")
+	if quoted {
+		return name
+	}
+	return strings.ToLower(name)
+}
+
+func splitNames(list string) []string {
+	names := []string{}
+	for _, name := range strings.Split(list, ",") {
+		names = append(names, normalizeName(name))
+	}
+	return names
+}
@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/auxten/postgresql-parser/pkg/sql/sem/tree"
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+// Custom write queries (INSERT, UPDATE, DELETE) are executed as written.
+// The parameters are typed by the columns they are assigned or compared to, and the result by the RETURNING list
+func parseWriteQuery(schema *core.SQLSchema, stmt tree.Statement, macro []core.QueryMacro) error {
+	query := core.SQLQuery{
+		Query: stmt.String(),
+	}
+
+	m := findQueryMacro(query.Query, macro)
+	if m == nil {
+		return nil
+	}
+	method, err := normalizeQueryMethod(m)
+	if err != nil {
+		return err
+	}
+	query.Method = method
+	query.Name = strings.TrimSpace(m.Name)
+	query.Comment = m.Comment
+
+	var returning tree.ReturningClause
+	tables := []core.TableDeps{}
+	exprs := []tree.Expr{}
+
+	switch s := stmt.(type) {
+	case *tree.Insert:
+		tables = append(tables, findWriteTable(s.Table))
+		exprs = append(exprs, findInsertAssignments(schema, tables[0], s)...)
+		if s.OnConflict != nil {
+			exprs = append(exprs, findUpdateAssignments(tables[0], s.OnConflict.Exprs)...)
+			exprs = append(exprs, whereExpr(s.OnConflict.Where))
+		}
+		returning = s.Returning
+		s.Returning = &tree.NoReturningClause{}
+	case *tree.Update:
+		tables = append(tables, findWriteTable(s.Table))
+		for _, from := range s.From {
+			tables = append(tables, findWriteTable(from))
+		}
+		exprs = append(exprs, findUpdateAssignments(tables[0], s.Exprs)...)
+		exprs = append(exprs, whereExpr(s.Where))
+		returning = s.Returning
+		s.Returning = &tree.NoReturningClause{}
+	case *tree.Delete:
+		tables = append(tables, findWriteTable(s.Table))
+		exprs = append(exprs, whereExpr(s.Where))
+		returning = s.Returning
+		s.Returning = &tree.NoReturningClause{}
+	default:
+		return nil
+	}
+	query.From = strings.Join(tables[0].Names, ", ")
+	if len(tables[0].Names) > 0 {
+		query.Table = tables[0].Names[0]
+	}
+
+	statement := stmt.String()
+	if fields, ok := returning.(*tree.ReturningExprs); ok {
+		for i, field := range *fields {
+			as := strings.TrimSpace(strings.Trim(field.As.String(), `"'`))
+			query.SelectFields = append(query.SelectFields, resolveTableColumns(field.Expr.String(), as, tables, schema, i)...)
+		}
+		slices.SortFunc(query.SelectFields, func(i, j *core.SQLColumn) int {
+			return i.Order - j.Order
+		})
+		query.Select = formatSelectFields(query.SelectFields)
+		statement += " RETURNING " + query.Select
+	}
+
+	if len(query.SelectFields) == 0 && (query.Method == core.QueryOne || query.Method == core.QueryMany) {
+		return macroError(m, "%s needs a RETURNING clause, use exec or execRows without it", query.Method)
+	}
+
+	query.Params = findQueryParams(schema, tables, m.Params, exprs...)
+	query.Statement, query.StatementParams = replacePositionalParams(statement, query.Params)
+
+	schema.Queries = append(schema.Queries, query)
+	return nil
+}
+
+func findWriteTable(expr tree.TableExpr) core.TableDeps {
+	switch s := expr.(type) {
+	case *tree.TableName:
+		return core.TableDeps{Names: []string{s.TableName.Normalize()}}
+	case *tree.AliasedTableExpr:
+		deps := findWriteTable(s.Expr)
+		deps.As = s.As.Alias.Normalize()
+		return deps
+	}
+	return core.TableDeps{}
+}
+
+func whereExpr(where *tree.Where) tree.Expr {
+	if where == nil {
+		return nil
+	}
+	return where.Expr
+}
+
+// Inserted values, as `column IS NOT DISTINCT FROM value` conditions (cf paramVisitor).
+// Without column list, the values are in the order of the table columns
+func findInsertAssignments(schema *core.SQLSchema, table core.TableDeps, stmt *tree.Insert) []tree.Expr {
+	if stmt.Rows == nil {
+		return nil
+	}
+
+	values, ok := stmt.Rows.Select.(*tree.ValuesClause)
+	if !ok {
+		return nil
+	}
+
+	columns := NameListToStrings(stmt.Columns)
+	if len(columns) == 0 && len(table.Names) > 0 {
+		if t, ok := schema.Tables[table.Names[0]]; ok {
+			cols := slices.Collect(maps.Values(t.Columns))
+			slices.SortFunc(cols, func(i, j *core.SQLColumn) int {
+				return i.Order - j.Order
+			})
+			for _, col := range cols {
+				columns = append(columns, col.Name)
+			}
+		}
+	}
+
+	exprs := []tree.Expr{}
+	for _, row := range values.Rows {
+		for i, value := range row {
+			if i < len(columns) {
+				exprs = append(exprs, assignment(table, columns[i], value))
+			}
+		}
+	}
+	return exprs
+}
+
+// Updated values (`SET column = value` or `SET (a, b) = (value, value)`), as assignment conditions
+func findUpdateAssignments(table core.TableDeps, updates tree.UpdateExprs) []tree.Expr {
+	exprs := []tree.Expr{}
+	for _, update := range updates {
+		if !update.Tuple {
+			exprs = append(exprs, assignment(table, update.Names[0].Normalize(), update.Expr))
+			continue
+		}
+
+		if tuple, ok := update.Expr.(*tree.Tuple); ok && len(tuple.Exprs) == len(update.Names) {
+			for i, name := range update.Names {
+				exprs = append(exprs, assignment(table, name.Normalize(), tuple.Exprs[i]))
+			}
+		}
+	}
+	return exprs
+}
+
+// Assigned value, as a null-safe comparison: the parameter takes the type and the nullability of the column
+func assignment(table core.TableDeps, column string, value tree.Expr) tree.Expr {
+	prefix := table.As
+	if prefix == "" && len(table.Names) > 0 {
+		prefix = table.Names[0]
+	}
+	name := tree.MakeUnresolvedName(prefix, column)
+	return &tree.ComparisonExpr{Operator: tree.IsNotDistinctFrom, Left: &name, Right: value}
+}
@@ -0,0 +1,406 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/auxten/postgresql-parser/pkg/sql/parser"
+	"github.com/auxten/postgresql-parser/pkg/sql/sem/tree"
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+// Problem found in a custom query, located in its file (ParseQueries only knows the line and the column)
+type QueryError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (err *QueryError) Error() string {
+	if err.File == "" {
+		return fmt.Sprintf("%d:%d: %s", err.Line, err.Column, err.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", err.File, err.Line, err.Column, err.Message)
+}
+
+// Validate the custom queries parsed from files (cf ParseQueries): queries without annotation, unknown methods, annotations
+// which do not generate a query, duplicate names, unknown tables and columns, ambiguous unqualified columns
+func ValidateQueries(schema *core.SQLSchema, files []core.QueryFile) []error {
+	errs := []error{}
+	names := map[string]*QueryError{}
+
+	for _, file := range files {
+		replaced, params := replaceNamedParams(normalizeQueries(file.SQL, schema.Driver))
+		statements := splitStatements(replaced)
+		offset := 0
+
+		for i, statement := range splitStatements(file.SQL) {
+			start := offset
+			offset += len(statement) + 1
+
+			wrapped, err := wrapFilter(statements[i])
+			if err != nil {
+				errs = append(errs, locateError(file, start, statement, "", err.Error()))
+				continue
+			}
+			stmts, err := parser.Parse(wrapped)
+			if err != nil {
+				errs = append(errs, locateError(file, start, statement, "", err.Error()))
+				continue
+			}
+			if len(stmts) == 0 {
+				continue
+			}
+
+			lines := strings.Split(statement, "\n")
+			idx := findMacroLine(lines)
+			if idx < 0 {
+				errs = append(errs, locateError(file, start, statement, "", "query without annotation (e.g. `-- queryMany: Name`)"))
+				continue
+			}
+
+			// the annotation and the body of the query
+			annotation := parseMacro.FindStringSubmatch(strings.TrimSpace(lines[idx]))
+			name := strings.TrimSpace(annotation[2])
+			location := locateError(file, start+lineOffset(lines, idx), lines[idx], "", "")
+			body := min(start+lineOffset(lines, idx+1), offset-1, len(file.SQL))
+
+			if first, ok := names[name]; ok {
+				location.Message = fmt.Sprintf("duplicate query name %s (first declared at %s:%d)", name, first.File, first.Line)
+				errs = append(errs, location)
+				continue
+			}
+			names[name] = location
+
+			if !slices.ContainsFunc(queryMethods, func(method string) bool { return strings.EqualFold(method, strings.TrimSpace(annotation[1])) }) {
+				location.Message = fmt.Sprintf("unknown query method %q", strings.TrimSpace(annotation[1]))
+				errs = append(errs, location)
+				continue
+			}
+
+			generated := func(query core.SQLQuery) bool { return query.Name == name }
+			if !slices.ContainsFunc(schema.Queries, generated) && !slices.ContainsFunc(schema.Filters, generated) {
+				location.Message = fmt.Sprintf("query %s is not generated, its statement is not supported", name)
+				errs = append(errs, location)
+				continue
+			}
+
+			var queryParams []string
+			if i < len(params) {
+				queryParams = params[i]
+			}
+			validator := &queryValidator{scope: newQueryScope(schema, queryParams)}
+			validator.statement(stmts[0].AST)
+			for _, issue := range validator.issues {
+				errs = append(errs, locateError(file, body, file.SQL[body:min(offset-1, len(file.SQL))], issue.token, issue.message))
+			}
+		}
+	}
+
+	return errs
+}
+
+// Offset of a line in a statement
+func lineOffset(lines []string, idx int) int {
+	offset := 0
+	for _, line := range lines[:min(idx, len(lines))] {
+		offset += len(line) + 1
+	}
+	return offset
+}
+
+// Position of a token in a query (its first non blank character without token)
+func locateError(file core.QueryFile, start int, sql string, token string, message string) *QueryError {
+	pos := start + len(sql) - len(strings.TrimLeft(sql, " \t\r\n"))
+	if token != "" {
+		parts := []string{}
+		for _, part := range strings.Split(token, ".") {
+			parts = append(parts, regexp.QuoteMeta(part))
+		}
+		reg := regexp.MustCompile(`(?i)\b` + strings.Join(parts, `\s*\.\s*`) + `\b`)
+		if match := reg.FindStringIndex(sql); match != nil {
+			pos = start + match[0]
+		}
+	}
+
+	pos = min(pos, len(file.SQL))
+	line := strings.Count(file.SQL[:pos], "\n") + 1
+	column := pos - strings.LastIndex(file.SQL[:pos], "\n")
+	return &QueryError{File: file.Path, Line: line, Column: column, Message: message}
+}
+
+type queryIssue struct {
+	message string
+	token   string
+}
+
+// Tables of a select (or written table), by name or alias. The table is nil when its columns are unknown.
+// The tables of the outer query can be used by a subquery
+type queryRelation struct {
+	name  string
+	table *core.SQLTable
+	outer bool
+}
+
+type queryValidator struct {
+	scope  *queryScope
+	issues []queryIssue
+}
+
+func (v *queryValidator) issue(token string, message string, args ...any) {
+	v.issues = append(v.issues, queryIssue{message: fmt.Sprintf(message, args...), token: token})
+}
+
+func (v *queryValidator) statement(stmt tree.Statement) {
+	switch s := stmt.(type) {
+	case *tree.Select:
+		v.selectStatement(s, nil)
+	case *tree.Insert:
+		relations := v.from(tree.TableExprs{s.Table})
+		if len(relations) == 0 {
+			return
+		}
+		v.writeColumns(relations, NameListToStrings(s.Columns))
+		if s.Rows != nil && s.Rows.Select != nil {
+			if _, ok := s.Rows.Select.(*tree.ValuesClause); !ok {
+				v.selectStatement(s.Rows, nil)
+			}
+		}
+		if s.OnConflict != nil {
+			excluded := append(relations, queryRelation{name: "excluded", table: relations[0].table})
+			for _, update := range s.OnConflict.Exprs {
+				v.writeColumns(relations, NameListToStrings(update.Names))
+				v.columns(update.Expr, excluded, nil)
+			}
+			v.columns(whereExpr(s.OnConflict.Where), excluded, nil)
+		}
+		v.returning(s.Returning, relations)
+	case *tree.Update:
+		relations := v.from(append(tree.TableExprs{s.Table}, s.From...))
+		if len(relations) == 0 {
+			return
+		}
+		for _, update := range s.Exprs {
+			v.writeColumns(relations[:1], NameListToStrings(update.Names))
+			v.columns(update.Expr, relations, nil)
+		}
+		v.columns(whereExpr(s.Where), relations, nil)
+		v.returning(s.Returning, relations)
+	case *tree.Delete:
+		relations := v.from(tree.TableExprs{s.Table})
+		v.columns(whereExpr(s.Where), relations, nil)
+		v.returning(s.Returning, relations)
+	}
+}
+
+func (v *queryValidator) selectStatement(stmt *tree.Select, outer []queryRelation) {
+	if stmt.With != nil {
+		for _, cte := range stmt.With.CTEList {
+			if sel, ok := cte.Stmt.(*tree.Select); ok {
+				v.selectStatement(sel, outer)
+				v.scope.addTable(cte.Name.Alias.Normalize(), NameListToStrings(cte.Name.Cols), sel)
+			}
+		}
+	}
+
+	clauses := selectClauses(stmt.Select)
+	for i, clause := range clauses {
+		relations, aliases := v.selectClause(clause, outer)
+		// the ORDER BY of a UNION refers to the output columns
+		if i == 0 && len(clauses) == 1 {
+			for _, order := range stmt.OrderBy {
+				v.columns(order.Expr, relations, aliases)
+			}
+		}
+	}
+}
+
+func (v *queryValidator) selectClause(clause *tree.SelectClause, outer []queryRelation) ([]queryRelation, []string) {
+	relations := v.from(clause.From.Tables)
+	for _, relation := range outer {
+		relation.outer = true
+		relations = append(relations, relation)
+	}
+
+	aliases := []string{}
+	for _, field := range clause.Exprs {
+		if field.As != "" {
+			aliases = append(aliases, tree.Name(field.As).Normalize())
+		}
+	}
+
+	for _, field := range clause.Exprs {
+		v.columns(field.Expr, relations, nil)
+	}
+	v.columns(whereExpr(clause.Where), relations, nil)
+	for _, group := range clause.GroupBy {
+		v.columns(group, relations, aliases)
+	}
+	v.columns(whereExpr(clause.Having), relations, aliases)
+
+	return relations, aliases
+}
+
+func (v *queryValidator) from(exprs tree.TableExprs) []queryRelation {
+	relations := []queryRelation{}
+	for _, expr := range exprs {
+		switch s := expr.(type) {
+		case *tree.TableName:
+			relations = append(relations, v.table(s.TableName.Normalize(), ""))
+		case *tree.AliasedTableExpr:
+			alias := s.As.Alias.Normalize()
+			switch t := s.Expr.(type) {
+			case *tree.TableName:
+				relations = append(relations, v.table(t.TableName.Normalize(), alias))
+			case *tree.Subquery:
+				if sel, ok := t.Select.(*tree.ParenSelect); ok {
+					v.selectStatement(sel.Select, nil)
+					v.scope.addTable(alias, NameListToStrings(s.As.Cols), sel.Select)
+				}
+				relations = append(relations, queryRelation{name: alias, table: v.scope.schema.Tables[alias]})
+			default:
+				v.issue(tableExprToken(t), "unsupported table expression %s in FROM, only tables and subqueries can be selected", tree.AsString(t))
+				relations = append(relations, queryRelation{name: alias})
+			}
+		case *tree.JoinTableExpr:
+			relations = append(relations, v.from(tree.TableExprs{s.Left, s.Right})...)
+			if cond, ok := s.Cond.(*tree.OnJoinCond); ok {
+				v.columns(cond.Expr, relations, nil)
+			}
+		case *tree.ParenTableExpr:
+			relations = append(relations, v.from(tree.TableExprs{s.Expr})...)
+		}
+	}
+	return relations
+}
+
+// Name of a table function, to locate it in the query
+func tableExprToken(expr tree.TableExpr) string {
+	if rows, ok := expr.(*tree.RowsFromExpr); ok && len(rows.Items) > 0 {
+		if fn, ok := rows.Items[0].(*tree.FuncExpr); ok {
+			return fn.Func.String()
+		}
+	}
+	return ""
+}
+
+func (v *queryValidator) table(name string, alias string) queryRelation {
+	table, ok := v.scope.schema.Tables[name]
+	if !ok {
+		v.issue(name, "unknown table %s", name)
+	}
+	if alias == "" {
+		alias = name
+	}
+	return queryRelation{name: alias, table: table}
+}
+
+func (v *queryValidator) writeColumns(relations []queryRelation, columns []string) {
+	if len(relations) == 0 || relations[0].table == nil {
+		return
+	}
+	for _, column := range columns {
+		if _, ok := relations[0].table.Columns[column]; !ok {
+			v.issue(column, "unknown column %s in table %s", column, relations[0].table.Name)
+		}
+	}
+}
+
+func (v *queryValidator) returning(returning tree.ReturningClause, relations []queryRelation) {
+	if fields, ok := returning.(*tree.ReturningExprs); ok {
+		for _, field := range *fields {
+			v.columns(field.Expr, relations, nil)
+		}
+	}
+}
+
+// Check the columns used by an expression, unqualified columns have to belong to a single table
+func (v *queryValidator) columns(expr tree.Expr, relations []queryRelation, aliases []string) {
+	if expr == nil {
+		return
+	}
+
+	visitor := &columnVisitor{}
+	tree.WalkExprConst(visitor, expr)
+
+	for _, subquery := range visitor.subqueries {
+		if sel, ok := subquery.Select.(*tree.ParenSelect); ok {
+			v.selectStatement(sel.Select, relations)
+		}
+	}
+
+	for _, name := range visitor.names {
+		if name.NumParts == 1 {
+			v.unqualifiedColumn(name, relations, aliases)
+			continue
+		}
+
+		idx := slices.IndexFunc(relations, func(relation queryRelation) bool {
+			return relation.name == name.Parts[1]
+		})
+		switch {
+		case idx < 0:
+			v.issue(name.String(), "unknown table %s", name.Parts[1])
+		case name.Star || relations[idx].table == nil:
+		case relations[idx].table.Columns[name.Parts[0]] == nil:
+			v.issue(name.String(), "unknown column %s", name.String())
+		}
+	}
+}
+
+func (v *queryValidator) unqualifiedColumn(name *tree.UnresolvedName, relations []queryRelation, aliases []string) {
+	if name.Star {
+		return
+	}
+
+	column := name.Parts[0]
+	tables := []string{}
+	for _, outer := range []bool{false, true} {
+		for _, relation := range relations {
+			if relation.outer != outer {
+				continue
+			}
+			if relation.table == nil {
+				return
+			}
+			if _, ok := relation.table.Columns[column]; ok {
+				tables = append(tables, relation.name)
+			}
+		}
+		// the columns of the subquery hide the ones of the outer query
+		if len(tables) > 0 {
+			break
+		}
+	}
+
+	switch {
+	case len(tables) > 1:
+		v.issue(column, "ambiguous column %s (in %s)", column, strings.Join(tables, ", "))
+	case len(tables) == 0 && !slices.Contains(aliases, column):
+		v.issue(column, "unknown column %s", column)
+	}
+}
+
+// Columns used by an expression, the subqueries are validated on their own
+type columnVisitor struct {
+	names      []*tree.UnresolvedName
+	subqueries []*tree.Subquery
+}
+
+func (v *columnVisitor) VisitPre(expr tree.Expr) (bool, tree.Expr) {
+	switch e := expr.(type) {
+	case *tree.UnresolvedName:
+		v.names = append(v.names, e)
+	case *tree.Subquery:
+		v.subqueries = append(v.subqueries, e)
+		return false, expr
+	}
+	return true, expr
+}
+
+func (v *columnVisitor) VisitPost(expr tree.Expr) tree.Expr {
+	return expr
+}
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/auxten/postgresql-parser/pkg/sql/parser"
+	"github.com/auxten/postgresql-parser/pkg/sql/sem/tree"
+	"github.com/kefniark/mango-sql/internal/core"
+)
+
+var (
+	regCreateView = regexp.MustCompile(`(?is)CREATE\s+(?:OR\s+REPLACE\s+)?(?:TEMP\s+|TEMPORARY\s+)?(?:ALGORITHM\s*=\s*\w+\s+)?(?:DEFINER\s*=\s*\S+\s+)?(?:SQL\s+SECURITY\s+\w+\s+)?(MATERIALIZED\s+)?VIEW\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."\x60]+)\s*(?:\(([^)]*)\))?\s+AS\s+`)
+	regViewData   = regexp.MustCompile(`(?is)\s*WITH\s+(?:NO\s+)?DATA\s*$`)
+	regDropView   = regexp.MustCompile(`(?i)DROP\s+(?:MATERIALIZED\s+)?VIEW\s+(?:IF\s+EXISTS\s+)?([\w."\x60]+)`)
+)
+
+type ViewContent struct {
+	Name         string
+	Columns      []string
+	Materialized bool
+	Query        string
+}
+
+// Views (and postgres materialized views) are not supported by the cockroachDB parser,
+// so they are extracted from the raw sql before normalization, in order of declaration (a dropped view is removed)
+func findViews(sql string) []ViewContent {
+	type viewMatch struct {
+		start int
+		view  *ViewContent
+		drop  string
+	}
+
+	matches := []viewMatch{}
+	for _, match := range regCreateView.FindAllStringSubmatchIndex(sql, -1) {
+		view := &ViewContent{
+			Name:         normalizeName(sql[match[4]:match[5]]),
+			Materialized: match[2] >= 0,
+			Query:        viewQuery(sql[match[1]:]),
+		}
+		if match[6] >= 0 {
+			view.Columns = splitNames(sql[match[6]:match[7]])
+		}
+		matches = append(matches, viewMatch{start: match[0], view: view})
+	}
+	for _, match := range regDropView.FindAllStringSubmatchIndex(sql, -1) {
+		matches = append(matches, viewMatch{start: match[0], drop: normalizeName(sql[match[2]:match[3]])})
+	}
+	slices.SortFunc(matches, func(a, b viewMatch) int {
+		return a.start - b.start
+	})
+
+	views := []ViewContent{}
+	for _, match := range matches {
+		name := match.drop
+		if match.view != nil {
+			name = match.view.Name
+		}
+		views = slices.DeleteFunc(views, func(view ViewContent) bool {
+			return view.Name == name
+		})
+		if match.view != nil {
+			views = append(views, *match.view)
+		}
+	}
+	return views
+}
+
+// Query of a view, up to the end of its statement (a `;` outside of a literal)
+func viewQuery(sql string) string {
+	query := strings.TrimSpace(splitStatements(sql)[0])
+	return replaceMysqlBacktips(regViewData.ReplaceAllString(query, ""))
+}
+
+// Add the views to the schema as read-only tables, their columns are typed from the tables they select (like a CTE).
+// A view can use the views declared before it
+func applyViews(schema *core.SQLSchema, views []ViewContent) error {
+	for _, view := range views {
+		stmts, err := parser.Parse(view.Query)
+		if err != nil {
+			return fmt.Errorf("view %s: its query cannot be parsed: %w", view.Name, err)
+		}
+		if len(stmts) != 1 {
+			return fmt.Errorf("view %s: its query is not a single statement", view.Name)
+		}
+		sel, ok := stmts[0].AST.(*tree.Select)
+		if !ok {
+			return fmt.Errorf("view %s: its query is not a select", view.Name)
+		}
+
+		scope := newQueryScope(schema, nil)
+		scope.addTable(view.Name, view.Columns, sel)
+		if scope.err != nil {
+			return fmt.Errorf("view %s: %w", view.Name, scope.err)
+		}
+		table := scope.schema.Tables[view.Name]
+		if table == nil || len(table.Columns) == 0 {
+			return fmt.Errorf("view %s: its columns cannot be resolved", view.Name)
+		}
+		for _, deps := range scope.resolve(&core.SQLQuery{}, firstSelectClause(sel.Select)) {
+			for _, name := range deps.Names {
+				if _, ok := scope.schema.Tables[name]; !ok {
+					return fmt.Errorf("view %s: unknown table %s", view.Name, name)
+				}
+			}
+		}
+
+		table.View = true
+		table.Materialized = view.Materialized
+		table.Order = len(schema.Tables) + 1
+		schema.Tables[view.Name] = table
+	}
+	return nil
+}
@@ -52,6 +52,21 @@ func BenchmarkMangoPostgresPGX(t *testing.B) {
 		})
 	}
 
+	for _, value := range samples {
+		t.Run("BulkInsert_"+strconv.Itoa(value), func(t *testing.B) {
+			for range t.N {
+				create := make([]driver_pgx.UserCreate, value)
+				for i := range len(create) {
+					create[i] = driver_pgx.UserCreate{Name: fmt.Sprintf("John Doe %d", i), Email: fmt.Sprintf("john+%d@email.com", i)}
+				}
+
+				count, err := dbMangoPgx.User.BulkInsert(create)
+				require.NoError(t, err)
+				assert.Equal(t, int64(value), count)
+			}
+		})
+	}
+
 	t.Run("FindById", func(t *testing.B) {
 		create := make([]driver_pgx.UserCreate, 10)
 		for i := range len(create) {
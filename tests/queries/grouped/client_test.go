@@ -0,0 +1,72 @@
+package grouped
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+//go:generate go run ../../../cmd/mangosql/ --output ./client.go --package grouped --driver sqlite --fake --queries ./queries --group-queries ./schema.sql
+
+func newTestDB(t *testing.T) (*DBClient, func()) {
+	t.Helper()
+	db, err := sqlx.Open("sqlite", ":memory:")
+	if err != nil {
+		panic(err)
+	}
+
+	data, err := os.ReadFile("./schema.sql")
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = db.Exec(string(data))
+	if err != nil {
+		panic(err)
+	}
+
+	return New(db), func() {
+		db.Close()
+	}
+}
+
+func TestGroupedQueries(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	_, err := db.User.Insert(UserCreate{Id: 1, Name: "user1"})
+	require.NoError(t, err)
+	_, err = db.Post.Insert(PostCreate{Id: 1, UserId: 1, Title: "post1"})
+	require.NoError(t, err)
+
+	// queries of every file of the folder are attached to the client of their table
+	users, err := db.User.NotDeleted()
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	// the method keeps its table prefix when it conflicts with a table method (Count)
+	count, err := db.User.UserCount()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count.Total)
+
+	posts, err := db.Post.ByUser(1)
+	require.NoError(t, err)
+	assert.Len(t, posts, 1)
+
+	// a query on a CTE stays in db.Queries
+	counts, err := db.Queries.PostCounts()
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	assert.Equal(t, int64(1), counts[0].CountsTotal)
+}
+
+func TestGroupedQueriesRepository(t *testing.T) {
+	var repository UserRepository = NewFake().UserRepository()
+
+	_, err := repository.NotDeleted()
+	require.Error(t, err)
+}
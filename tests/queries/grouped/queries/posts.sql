@@ -0,0 +1,11 @@
+-- queryMany: PostsByUser
+SELECT posts.id, posts.title
+FROM posts
+WHERE posts.user_id = @user_id;
+
+-- queryMany: PostCounts
+WITH counts AS (
+  SELECT posts.user_id, count(*) AS total FROM posts GROUP BY posts.user_id
+)
+SELECT counts.user_id, counts.total
+FROM counts;
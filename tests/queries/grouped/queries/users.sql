@@ -0,0 +1,8 @@
+-- queryMany: UserNotDeleted
+SELECT *
+FROM users
+WHERE users.deleted_at IS NULL;
+
+-- queryOne: UserCount
+SELECT count(*) AS total
+FROM users;
@@ -0,0 +1,11 @@
+CREATE TABLE users (
+  id          INTEGER PRIMARY KEY,
+  name        VARCHAR(64) NOT NULL,
+  deleted_at  TIMESTAMP
+);
+
+CREATE TABLE posts (
+  id          INTEGER PRIMARY KEY,
+  user_id     INTEGER NOT NULL REFERENCES users(id),
+  title       VARCHAR(12) NOT NULL
+);
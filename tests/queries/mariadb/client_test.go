@@ -14,7 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-//go:generate go run ../../../cmd/mangosql/ --output ./client.go --package mariadb --driver mariadb --logger console ./schema.sql
+//go:generate go run ../../../cmd/mangosql/ --output ./client.go --package mariadb --driver mariadb --logger console --fake --factories ./schema.sql
 
 //go:embed *.sql
 var sqlPqFS embed.FS
@@ -452,6 +452,134 @@ func TestFindIn(t *testing.T) {
 	assert.Len(t, users, 2)
 }
 
+func TestFindSearch(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i, name := range []string{"tuna fish", "salmon fish", "bob"} {
+		_, err := db.User.Insert(UserCreate{Id: int64(i + 1), Name: name})
+		require.NoError(t, err)
+	}
+
+	users, err := db.User.FindMany(db.User.Query.Search("fish"))
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	count, err := db.User.Count(db.User.Query.Search("salmon"), db.User.Query.Id.GreaterThan(1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestFindText(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i, name := range []string{"Tuna Fish", "salmon fish", "100% bob", "1000 bobs"} {
+		_, err := db.User.Insert(UserCreate{Id: int64(i + 1), Name: name})
+		require.NoError(t, err)
+	}
+
+	count, err := db.User.Count(db.User.Query.Name.ILike("%FISH"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = db.User.Count(db.User.Query.Name.NotILike("%FISH"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = db.User.Count(db.User.Query.Name.StartsWith("100%"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = db.User.Count(db.User.Query.Name.EndsWith(" bobs"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = db.User.Count(db.User.Query.Name.ContainsText("0% B"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = db.User.Count(db.User.Query.Name.Regex("^[0-9]+ bobs$"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestFindJSON(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	_, err := db.Profile.Insert(ProfileCreate{
+		Id:       1,
+		Settings: JSON[ProfileSettings]{Data: ProfileSettings{Theme: "dark", Tags: []string{"admin", "dev"}}},
+		Metadata: &JSON[map[string]any]{Data: map[string]any{"lang": "en", "nested": map[string]any{"level": 2}}},
+	})
+	require.NoError(t, err)
+
+	_, err = db.Profile.InsertMany([]ProfileCreate{{Id: 2, Settings: JSON[ProfileSettings]{Data: ProfileSettings{Theme: "light"}}}})
+	require.NoError(t, err)
+
+	profile, err := db.Profile.FindById(1)
+	require.NoError(t, err)
+	assert.Equal(t, "dark", profile.Settings.Data.Theme)
+	assert.Equal(t, []string{"admin", "dev"}, profile.Settings.Data.Tags)
+	assert.Equal(t, "en", (*profile.Metadata).Data["lang"])
+
+	count, err := db.Profile.Count(db.Profile.Query.Settings.HasKey("theme"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = db.Profile.Count(db.Profile.Query.Metadata.HasKey("nested.level"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = db.Profile.Count(db.Profile.Query.Settings.PathEquals("theme", "light"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = db.Profile.Count(db.Profile.Query.Metadata.PathEquals("nested.level", "2"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = db.Profile.Count(db.Profile.Query.Settings.Contains(map[string]any{"tags": []string{"admin"}}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = db.Profile.Count(db.Profile.Query.Metadata.IsNull())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestRowLock(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i := 1; i <= 3; i++ {
+		_, err := db.User.Insert(UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+		require.NoError(t, err)
+	}
+
+	_, err := db.User.FindMany(db.User.Query.ForUpdate())
+	require.ErrorIs(t, err, errRowLockOutsideTransaction)
+	_, err = db.Queries.LatestPosts(1, 5, db.Post.Query.ForUpdate())
+	require.ErrorIs(t, err, errRowLockOutsideTransaction)
+
+	err = db.Transaction(func(tx *DBClient) error {
+		users, err := tx.User.FindMany(tx.User.Query.Id.OrderAsc(), tx.User.Query.Limit(2), tx.User.Query.ForUpdate(), tx.User.Query.SkipLocked())
+		require.NoError(t, err)
+		assert.Len(t, users, 2)
+
+		// the lock clause does not depend on the order of the filters
+		users, err = tx.User.FindMany(tx.User.Query.NoWait(), tx.User.Query.ForShare())
+		require.NoError(t, err)
+		assert.Len(t, users, 3)
+
+		_, err = tx.User.Count(tx.User.Query.ForUpdate())
+		require.ErrorIs(t, err, errRowLockCount)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestFindCustomFilter(t *testing.T) {
 	db, closeDB := newTestDB(t)
 	defer closeDB()
@@ -496,6 +624,288 @@ func TestFindCustomQuery(t *testing.T) {
 	assert.Len(t, users, 4)
 }
 
+func TestFindCustomQueryParams(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i := 1; i <= 2; i++ {
+		_, err := db.User.Insert(UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+		require.NoError(t, err)
+	}
+	for i, status := range []string{"draft", "published", "draft"} {
+		_, err := db.Post.Insert(PostCreate{Id: int64(i + 1), UserId: 1, Title: fmt.Sprintf("post%d", i+1), Status: status})
+		require.NoError(t, err)
+	}
+
+	// named parameters (@user_id, @status)
+	posts, err := db.Queries.UserPosts(1, "draft")
+	require.NoError(t, err)
+	assert.Len(t, posts, 3)
+
+	posts, err = db.Queries.UserPosts(2, "draft")
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+
+	// positional and sqlc style parameters ($1, sqlc.arg(min_id))
+	titles, err := db.Queries.PostsByTitle("post%", 1)
+	require.NoError(t, err)
+	assert.Len(t, titles, 2)
+}
+
+func TestFindCustomQueryOne(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	_, err := db.User.Insert(UserCreate{Id: 1, Name: "tuna"})
+	require.NoError(t, err)
+
+	user, err := db.Queries.UserByName("tuna")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), user.UsersId)
+
+	_, err = db.Queries.UserByName("salmon")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCustomWriteQueries(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	_, err := db.User.Insert(UserCreate{Id: 1, Name: "tuna"})
+	require.NoError(t, err)
+
+	// mysql has no RETURNING clause, the insert is only executed
+	for i := 1; i <= 2; i++ {
+		err = db.Queries.CreatePost(CreatePostParams{Id: int64(i), UserId: 1, Title: fmt.Sprintf("post%d", i), Status: "draft"})
+		require.NoError(t, err)
+	}
+
+	count, err := db.Queries.PublishPosts(PublishPostsParams{UserId: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = db.Queries.PublishPosts(PublishPostsParams{UserId: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	err = db.Queries.DeletePost(DeletePostParams{Id: 1})
+	require.NoError(t, err)
+
+	posts, err := db.Post.FindMany()
+	require.NoError(t, err)
+	assert.Len(t, posts, 1)
+}
+
+func TestFindCustomQuerySubqueries(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i := 1; i <= 2; i++ {
+		_, err := db.User.Insert(UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+		require.NoError(t, err)
+	}
+	for i, status := range []string{"draft", "published", "draft"} {
+		_, err := db.Post.Insert(PostCreate{Id: int64(i + 1), UserId: 1, Title: fmt.Sprintf("post%d", i+1), Status: status})
+		require.NoError(t, err)
+	}
+
+	// CTE
+	counts, err := db.Queries.UserPostCount(1)
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	assert.Equal(t, "user1", counts[0].UsersName)
+	assert.Equal(t, int64(3), counts[0].CountsTotal)
+
+	// derived table
+	drafts, err := db.Queries.DraftTitles(1)
+	require.NoError(t, err)
+	assert.Len(t, drafts, 2)
+
+	// filters apply to the rows of the query
+	counts, err = db.Queries.UserPostCount(1, func(query SelectBuilder) SelectBuilder {
+		return query.Where("q.users_name = ?", "user2")
+	})
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+
+	drafts, err = db.Queries.DraftTitles(1, func(query SelectBuilder) SelectBuilder {
+		return query.Where("q.drafts_title = ?", "post3")
+	})
+	require.NoError(t, err)
+	assert.Len(t, drafts, 1)
+}
+
+func TestFindCustomQueryExpressionTypes(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	_, err := db.User.Insert(UserCreate{Id: 1, Name: "tuna"})
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		_, err = db.Post.Insert(PostCreate{Id: int64(i), UserId: 1, Title: fmt.Sprintf("post%d", i), Status: "draft"})
+		require.NoError(t, err)
+	}
+
+	stats, err := db.Queries.PostStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Total)
+	assert.Equal(t, int64(3), *stats.LastId)
+	assert.Equal(t, "POST3", *stats.LastTitle)
+}
+
+func TestFindCustomQueryNullability(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i := 1; i <= 2; i++ {
+		_, err := db.User.Insert(UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+		require.NoError(t, err)
+	}
+	_, err := db.Post.Insert(PostCreate{Id: 1, UserId: 1, Title: "post1", Status: "draft"})
+	require.NoError(t, err)
+
+	// the columns of a LEFT JOIN table are nullable, even when NOT NULL in the schema
+	users, err := db.Queries.UsersWithPosts()
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	titles := map[string]*string{}
+	for _, user := range users {
+		titles[user.UsersName] = user.PostsTitle
+	}
+	require.NotNil(t, titles["user1"])
+	assert.Equal(t, "post1", *titles["user1"])
+	assert.Nil(t, titles["user2"])
+}
+
+func TestFindCustomQueryNested(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i := 1; i <= 2; i++ {
+		_, err := db.User.Insert(UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+		require.NoError(t, err)
+	}
+	for i := 1; i <= 3; i++ {
+		_, err := db.Post.Insert(PostCreate{Id: int64(i), UserId: 1, Title: fmt.Sprintf("post%d", i), Status: "draft"})
+		require.NoError(t, err)
+	}
+
+	// one entry per user, with their posts (-- nest: posts)
+	users, err := db.Queries.UsersWithPostsNested()
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	posts := map[string][]UsersWithPostsNestedPost{}
+	for _, user := range users {
+		posts[user.UsersName] = user.Posts
+	}
+	assert.Len(t, posts["user1"], 3)
+	assert.Empty(t, posts["user2"])
+}
+
+func TestFindCustomQueryOrdered(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i := 1; i <= 2; i++ {
+		_, err := db.User.Insert(UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+		require.NoError(t, err)
+	}
+	for i, status := range []string{"draft", "published", "draft"} {
+		_, err := db.Post.Insert(PostCreate{Id: int64(i + 1), UserId: 1, Title: fmt.Sprintf("post%d", i+1), Status: status})
+		require.NoError(t, err)
+	}
+
+	// ORDER BY, LIMIT and OFFSET are kept
+	posts, err := db.Queries.LatestPosts(1, 1)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "post2", posts[0].PostsTitle)
+	assert.Equal(t, int64(2), posts[0].Position)
+
+	// filters are added to the WHERE clause
+	posts, err = db.Queries.LatestPosts(1, 5, db.Post.Query.Status.Equal("draft"))
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "post1", posts[0].PostsTitle)
+
+	authors, err := db.Queries.PostAuthors()
+	require.NoError(t, err)
+	require.Len(t, authors, 1)
+	assert.Equal(t, "user1", authors[0].UsersName)
+
+	titles, err := db.Queries.UserAndPostTitles("draft")
+	require.NoError(t, err)
+	names := []string{}
+	for _, title := range titles {
+		names = append(names, title.UsersName)
+	}
+	assert.Equal(t, []string{"post1", "post3", "user1", "user2"}, names)
+
+	// filters apply to the rows of the union
+	titles, err = db.Queries.UserAndPostTitles("draft", func(query SelectBuilder) SelectBuilder {
+		return query.Where("q.users_name LIKE ?", "user%")
+	})
+	require.NoError(t, err)
+	assert.Len(t, titles, 2)
+}
+
+func TestNamedFilters(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i := 1; i <= 3; i++ {
+		_, err := db.User.Insert(UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.User.DeleteSoft(2))
+	for i, status := range []string{"draft", "published", "published"} {
+		_, err := db.Post.Insert(PostCreate{Id: int64(i + 1), UserId: 1, Title: fmt.Sprintf("post%d", i+1), Status: status})
+		require.NoError(t, err)
+	}
+
+	users, err := db.User.FindMany(db.User.Query.Active(), db.User.Query.Name.OrderAsc())
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "user1", users[0].Name)
+	assert.Equal(t, "user3", users[1].Name)
+
+	// composable with the other filters, and usable by the custom queries
+	posts, err := db.Post.FindMany(db.Post.Query.TitleLike("post%"), db.Post.Query.Id.LesserThan(3))
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "post2", posts[0].Title)
+
+	latest, err := db.Queries.LatestPosts(1, 10, db.Post.Query.TitleLike("post%"))
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	assert.Equal(t, "post2", latest[0].PostsTitle)
+}
+
+func TestFindCustomQueryDialect(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	for i := 1; i <= 3; i++ {
+		_, err := db.User.Insert(UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+		require.NoError(t, err)
+	}
+	for i := 1; i <= 2; i++ {
+		_, err := db.Post.Insert(PostCreate{Id: int64(i), UserId: 2, Title: fmt.Sprintf("post%d", i), Status: "draft"})
+		require.NoError(t, err)
+	}
+
+	// backticks, IFNULL, GROUP_CONCAT ... SEPARATOR, REGEXP and LIMIT offset, count are kept as mysql syntax
+	users, err := db.Queries.UserPostTitles(2, 1)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "user2", users[0].UsersName)
+	assert.Equal(t, "post1, post2", users[0].Titles)
+	assert.Equal(t, "user3", users[1].UsersName)
+	assert.Equal(t, "", users[1].Titles)
+}
+
 func TestModel(t *testing.T) {
 	db, closeDB := newTestDB(t)
 	defer closeDB()
@@ -587,3 +997,206 @@ func TestFilters(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 1, u)
 }
+
+func TestChunks(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	inputs := make([]UserCreate, 0, 25)
+	for i := 1; i <= 25; i++ {
+		inputs = append(inputs, UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+	}
+
+	_, err := db.WithChunkSize(10).WithConcurrentChunks(3).User.InsertMany(inputs)
+	require.NoError(t, err)
+
+	count, err := db.User.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 25, count)
+
+	updates := make([]UserUpdate, 0, 25)
+	for i := 1; i <= 25; i++ {
+		updates = append(updates, UserUpdate{Id: int64(i), Name: fmt.Sprintf("fish%d", i)})
+	}
+
+	// inside a transaction, the chunks are executed sequentially
+	err = db.WithChunkSize(7).WithConcurrentChunks(3).Transaction(func(tx *DBClient) error {
+		_, err := tx.User.UpdateMany(updates)
+		return err
+	})
+	require.NoError(t, err)
+
+	users, err := db.User.FindMany(db.User.Query.Name.StartsWith("fish"))
+	require.NoError(t, err)
+	assert.Len(t, users, 25)
+}
+
+// countUsers only depends on the generated interfaces, the database can be substituted
+func countUsers(db DBRepository, name string) (int, error) {
+	users := db.UserRepository()
+	return users.Count(users.Filters().Name.Equal(name))
+}
+
+type fakeUserRepository struct {
+	UserRepository
+	count int
+}
+
+func (f fakeUserRepository) Filters() UserFilters {
+	return UserFilters{}
+}
+
+func (f fakeUserRepository) Count(_ ...WhereCondition) (int, error) {
+	return f.count, nil
+}
+
+type fakeDB struct {
+	DBRepository
+	users UserRepository
+}
+
+func (f fakeDB) UserRepository() UserRepository {
+	return f.users
+}
+
+func TestRepository(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	err := db.InTransaction(func(tx DBRepository) error {
+		_, err := tx.UserRepository().Insert(UserCreate{Id: 1, Name: "tuna"})
+		return err
+	})
+	require.NoError(t, err)
+
+	count, err := countUsers(db, "tuna")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = countUsers(fakeDB{users: fakeUserRepository{count: 42}}, "tuna")
+	require.NoError(t, err)
+	assert.Equal(t, 42, count)
+}
+
+func TestFake(t *testing.T) {
+	db := NewFake()
+	users := db.UserRepository()
+
+	for i := 1; i <= 5; i++ {
+		_, err := users.Insert(UserCreate{Id: int64(i), Name: fmt.Sprintf("user%d", i)})
+		require.NoError(t, err)
+	}
+
+	// primary key enforced
+	_, err := users.Insert(UserCreate{Id: 1, Name: "duplicate"})
+	require.ErrorIs(t, err, ErrFakeConstraint)
+
+	// unique constraint enforced, none of the rows is written when one of them fails
+	posts := db.PostRepository()
+	_, err = posts.InsertMany([]PostCreate{
+		{Id: 1, UserId: 1, Title: "mango", Status: "draft"},
+		{Id: 2, UserId: 1, Title: "mango", Status: "draft"},
+	})
+	require.ErrorIs(t, err, ErrFakeConstraint)
+	_, err = posts.UpsertMany([]PostUpdate{
+		{Id: 1, UserId: 1, Title: "mango", Status: "draft"},
+		{Id: 2, UserId: 1, Title: "mango", Status: "draft"},
+	})
+	require.ErrorIs(t, err, ErrFakeConstraint)
+	postCount, err := posts.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 0, postCount)
+
+	// an update failing on its second row keeps the first one unchanged
+	_, err = posts.InsertMany([]PostCreate{
+		{Id: 1, UserId: 1, Title: "mango", Status: "draft"},
+		{Id: 2, UserId: 1, Title: "tuna", Status: "draft"},
+	})
+	require.NoError(t, err)
+	_, err = posts.UpdateMany([]PostUpdate{
+		{Id: 1, UserId: 1, Title: "banana", Status: "draft"},
+		{Id: 2, UserId: 1, Title: "banana", Status: "draft"},
+	})
+	require.ErrorIs(t, err, ErrFakeConstraint)
+	post, err := posts.FindById(1)
+	require.NoError(t, err)
+	assert.Equal(t, "mango", post.Title)
+
+	// typed filters evaluated in memory
+	found, err := users.FindMany(
+		users.Filters().Id.GreaterThan(1),
+		users.Filters().Name.Like("user%"),
+		users.Filters().Id.OrderDesc(),
+		users.Filters().Limit(2),
+	)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "user5", found[0].Name)
+	assert.Equal(t, "user4", found[1].Name)
+
+	user, err := users.Update(UserUpdate{Id: 2, Name: "tuna"})
+	require.NoError(t, err)
+	assert.Equal(t, "tuna", user.Name)
+
+	require.NoError(t, users.DeleteSoft(3))
+	count, err := users.Count(users.Filters().DeletedAt.IsNull())
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	require.NoError(t, users.DeleteHard(4))
+	_, err = users.FindById(4)
+	require.Error(t, err)
+
+	// changes reverted when the transaction fails
+	err = db.InTransaction(func(tx DBRepository) error {
+		_, err := tx.UserRepository().Insert(UserCreate{Id: 6, Name: "user6"})
+		require.NoError(t, err)
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+	count, err = users.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	// hand-written filters cannot be evaluated
+	_, err = users.FindMany(func(cond SelectBuilder) SelectBuilder {
+		return cond.Where("name = ?", "user1")
+	})
+	require.Error(t, err)
+}
+
+func TestFactories(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+
+	testFactories(t, db)
+	testFactories(t, NewFake())
+}
+
+func testFactories(t *testing.T, db DBRepository) {
+	factory := NewFactory(db)
+
+	// the referenced user is created first
+	post, err := factory.Post()
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(post.Title), 12)
+	assert.Contains(t, []string{"draft", "published"}, post.Status)
+
+	user, err := db.UserRepository().FindById(post.UserId)
+	require.NoError(t, err)
+	assert.NotEmpty(t, user.Name)
+
+	// overrides, the user is reused
+	post2, err := factory.Post(func(input *PostCreate) {
+		input.UserId = user.Id
+		input.Status = "published"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, user.Id, post2.UserId)
+	assert.Equal(t, "published", post2.Status)
+	assert.NotEqual(t, post.Title, post2.Title)
+
+	count, err := db.UserRepository().Count()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -2,5 +2,20 @@ CREATE TABLE users (
   id          INTEGER PRIMARY KEY,
   name        VARCHAR(64) NOT NULL,
   created_at  DATETIME NOT NULL DEFAULT NOW(),
-  deleted_at  DATETIME
-);
\ No newline at end of file
+  deleted_at  DATETIME COMMENT 'Set when the user is soft deleted'
+);
+
+CREATE TABLE profiles (
+  id          INTEGER PRIMARY KEY,
+  settings    JSON NOT NULL, -- type: ProfileSettings
+  metadata    JSON -- type: map[string]any
+);
+
+CREATE TABLE posts (
+  id          INTEGER PRIMARY KEY,
+  user_id     INTEGER NOT NULL REFERENCES users(id),
+  title       VARCHAR(12) NOT NULL UNIQUE,
+  status      ENUM('draft', 'published') NOT NULL
+) COMMENT='Blog posts, written by the users';
+
+CREATE FULLTEXT INDEX users_name_search ON users (name);
@@ -0,0 +1,7 @@
+package mariadb
+
+// Go type bound to the json column profiles.settings
+type ProfileSettings struct {
+	Theme string   `json:"theme"`
+	Tags  []string `json:"tags"`
+}
@@ -3,4 +3,31 @@ CREATE TABLE users (
   name        VARCHAR(64) NOT NULL,
   created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
   deleted_at  TIMESTAMP
-);
\ No newline at end of file
+);
+
+CREATE TABLE profiles (
+  id          INTEGER PRIMARY KEY,
+  settings    JSONB NOT NULL, -- type: ProfileSettings
+  metadata    JSONB -- type: map[string]any
+);
+
+CREATE TYPE post_status AS ENUM ('draft', 'published');
+
+CREATE TABLE posts (
+  id          INTEGER PRIMARY KEY,
+  user_id     INTEGER NOT NULL REFERENCES users(id),
+  title       VARCHAR(12) NOT NULL UNIQUE,
+  status      post_status NOT NULL
+);
+
+COMMENT ON TABLE posts IS 'Blog posts, written by the users';
+COMMENT ON COLUMN users.deleted_at IS 'Set when the user is soft deleted';
+
+CREATE INDEX users_name_search ON users USING GIN (to_tsvector('english', name));
+
+-- Number of posts of each user, refreshed on demand
+CREATE MATERIALIZED VIEW user_stats AS
+  SELECT users.id, users.name, COUNT(posts.id) AS posts
+  FROM users
+  LEFT JOIN posts ON posts.user_id = users.id
+  GROUP BY users.id, users.name;
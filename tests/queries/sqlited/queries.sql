@@ -1,4 +1,96 @@
+-- Users which are not soft deleted.
+-- Note: the deleted users are kept for the statistics
 -- queryMany: UserNotDeleted
 SELECT *
 FROM users
 WHERE users.deleted_at IS NULL;
+
+-- queryMany: UserPosts
+SELECT posts.id, posts.title, posts.status
+FROM posts
+WHERE posts.user_id = @user_id AND posts.status IN (@status, 'published');
+
+-- queryMany: PostsByTitle
+SELECT *
+FROM posts
+WHERE posts.title LIKE $1 AND posts.id > sqlc.arg(min_id);
+
+-- queryOne: UserByName
+SELECT *
+FROM users
+WHERE users.name = @name;
+
+-- queryOne: CreatePost
+INSERT INTO posts (id, user_id, title, status)
+VALUES (@id, @user_id, @title, @status)
+RETURNING *;
+
+-- execRows: PublishPosts
+UPDATE posts SET status = 'published'
+WHERE posts.user_id = @user_id AND posts.status = 'draft';
+
+-- exec: DeletePost
+DELETE FROM posts WHERE posts.id = @id;
+
+-- queryMany: UserPostCount
+WITH counts AS (
+  SELECT posts.user_id, count(*) AS total FROM posts GROUP BY posts.user_id
+)
+SELECT users.name, counts.total
+FROM users
+JOIN counts ON counts.user_id = users.id
+WHERE counts.total >= @min_total;
+
+-- queryMany: DraftTitles
+SELECT drafts.title
+FROM (SELECT posts.title FROM posts WHERE posts.status = 'draft' AND posts.user_id = @user_id) AS drafts;
+
+-- queryOne: PostStats
+SELECT count(*) AS total, max(posts.id) AS last_id, upper(max(posts.title)) AS last_title
+FROM posts;
+
+-- queryMany: UsersWithPosts
+SELECT users.name, posts.title
+FROM users
+LEFT JOIN posts ON posts.user_id = users.id;
+
+-- queryMany: UsersWithPostsNested
+-- nest: posts
+SELECT users.id, users.name, posts.id, posts.title
+FROM users
+LEFT JOIN posts ON posts.user_id = users.id;
+
+-- queryMany: LatestPosts
+SELECT posts.id, posts.title, row_number() OVER (ORDER BY posts.id) AS position
+FROM posts
+WHERE posts.user_id = @user_id
+ORDER BY posts.id DESC
+LIMIT @page_size OFFSET 1;
+
+-- queryMany: PostAuthors
+SELECT DISTINCT users.name
+FROM users
+JOIN posts ON posts.user_id = users.id
+ORDER BY users.name;
+
+-- queryMany: UserAndPostTitles
+SELECT users.name FROM users
+UNION
+SELECT posts.title FROM posts WHERE posts.status = @status
+ORDER BY name;
+
+-- queryMany: UserPostTitles
+SELECT users.name, IFNULL(group_concat(posts.title, ', '), '') AS titles
+FROM users
+LEFT JOIN posts ON posts.user_id = users.id
+WHERE users.name GLOB 'user*'
+GROUP BY users.id, users.name
+ORDER BY users.name
+LIMIT @skip, @take;
+
+-- Users which are not soft deleted
+-- filter: Active
+users.deleted_at IS NULL;
+
+-- filter: TitleLike
+posts.title LIKE @pattern AND posts.status <> 'deleted';
@@ -3,4 +3,40 @@ CREATE TABLE users (
   name        VARCHAR(64) NOT NULL,
   created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   deleted_at  TIMESTAMP
-);
\ No newline at end of file
+);
+
+CREATE TABLE profiles (
+  id          INTEGER PRIMARY KEY,
+  settings    JSON NOT NULL, -- type: ProfileSettings
+  metadata    JSON -- type: map[string]any
+);
+
+CREATE TABLE posts (
+  id          INTEGER PRIMARY KEY,
+  user_id     INTEGER NOT NULL REFERENCES users(id),
+  title       VARCHAR(12) NOT NULL UNIQUE,
+  status      TEXT NOT NULL
+);
+
+CREATE VIRTUAL TABLE users_search USING fts5(name, content='users', content_rowid='id');
+
+CREATE TRIGGER users_search_insert AFTER INSERT ON users BEGIN
+  INSERT INTO users_search(rowid, name) VALUES (new.id, new.name);
+END;
+
+CREATE TRIGGER users_search_delete AFTER DELETE ON users BEGIN
+  INSERT INTO users_search(users_search, rowid, name) VALUES ('delete', old.id, old.name);
+END;
+
+CREATE TRIGGER users_search_update AFTER UPDATE ON users BEGIN
+  INSERT INTO users_search(users_search, rowid, name) VALUES ('delete', old.id, old.name);
+  INSERT INTO users_search(rowid, name) VALUES (new.id, new.name);
+END;
+
+-- Users which are not deleted, with the number of posts they wrote
+CREATE VIEW active_users AS
+  SELECT users.id, users.name, COUNT(posts.id) AS posts
+  FROM users
+  LEFT JOIN posts ON posts.user_id = users.id
+  WHERE users.deleted_at IS NULL
+  GROUP BY users.id, users.name;